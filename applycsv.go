@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	commands = append(commands, &command{
+		name:     "apply-csv",
+		synopsis: "renames.csv",
+		brief:    "rename files as listed in a CSV or TSV file of old and new names",
+		run:      runApplyCSV,
+	})
+}
+
+// a rename of apply-csv, checked before any file is renamed
+type csvRename struct {
+	oldName string // the path in the file
+	name    string // the path after the renames of directories in earlier rows
+	newName string
+	entry   os.FileInfo
+}
+
+func runApplyCSV(fs *flag.FlagSet, args []string) (err error) {
+	sepFlag := fs.String("sep", "", "field separator; a tab for .tsv files and a comma otherwise")
+	fs.BoolVar(&dryrun, "d", dryrun, "dry run; print renames without renaming")
+	fs.StringVar(&reportFile, "report", reportFile, "write a JSON report of renamed files to this `file`")
+	fs.BoolVar(&force, "force", force, "rename protected locations")
+	fs.BoolVar(&asciiEscape, "ascii-escape", asciiEscape, "escape non-ASCII characters in printed names as \\uXXXX")
+	fs.Parse(args)
+	setASCIIEscape(asciiEscape)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	filename := fs.Arg(0)
+	runForm = "CSV"
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	switch {
+	case *sepFlag != "":
+		r.Comma = []rune(*sepFlag)[0]
+	case strings.EqualFold(filepath.Ext(filename), ".tsv"):
+		r.Comma = '\t'
+	}
+
+	moved := make(map[string]string) // directories renamed in this run, old to new
+	current := func(name string) string {
+		for d := name; ; d = filepath.Dir(d) {
+			if n, ok := moved[d]; ok {
+				rel, _ := filepath.Rel(d, name)
+				return filepath.Join(n, rel)
+			}
+			if filepath.Dir(d) == d {
+				return name
+			}
+		}
+	}
+
+	// check every row before renaming anything
+	var plan []csvRename
+	for first := true; ; first = false {
+		var row []string
+		row, err = r.Read()
+		if err == io.EOF {
+			err = nil
+			break
+		}
+		if err != nil {
+			return
+		}
+		line, _ := r.FieldPos(0)
+		if first && strings.EqualFold(strings.TrimSpace(row[0]), "old") {
+			continue // header
+		}
+		if len(row) < 2 || row[0] == "" || row[1] == "" {
+			return fmt.Errorf("%s:%d: expected old and new names", filename, line)
+		}
+
+		oldName := filepath.Clean(row[0])
+		name := current(oldName) // the path after earlier renames
+		newName := row[1]
+		if filepath.Base(newName) == newName {
+			newName = filepath.Join(filepath.Dir(name), newName)
+		}
+		newName = filepath.Clean(newName)
+		switch filepath.Dir(newName) {
+		case filepath.Dir(name):
+		case filepath.Dir(oldName): // given with the old directory names
+			newName = filepath.Join(filepath.Dir(name), filepath.Base(newName))
+		default:
+			return fmt.Errorf("%s:%d: '%s' is not in the directory of '%s'; only names can be changed", filename, line, row[1], row[0])
+		}
+		newf := filepath.Base(newName)
+		if newName == name {
+			continue
+		}
+		if !force {
+			err = checkProtected(oldName)
+			if err != nil {
+				return
+			}
+		}
+
+		entry, e := fsys.Lstat(oldName)
+		if e != nil {
+			addProblem(oldName, newf, e.Error())
+			continue
+		}
+		if reason := collision(oldName, entry, newf); reason != "" {
+			addProblem(oldName, newf, reason)
+			continue
+		}
+		if entry.IsDir() {
+			moved[oldName] = newName
+		}
+		plan = append(plan, csvRename{oldName: oldName, name: name, newName: newName, entry: entry})
+	}
+
+	for _, p := range plan {
+		actual := p.oldName
+		if !dryrun {
+			actual = p.name
+			if e := fsys.Rename(actual, p.newName); e != nil {
+				addProblem(actual, filepath.Base(p.newName), e.Error())
+				continue
+			}
+			actual = p.newName
+		}
+		fmt.Fprintf(stdout, "%s\n  -> %s\n", displayPath(p.name), displayPath(p.newName))
+		fileCount++
+		o, e1 := reportPath(p.oldName)
+		n, e2 := reportPath(p.newName)
+		if e1 == nil && e2 == nil {
+			reportEntries = append(reportEntries, reportEntry{Old: o, New: n, ID: fileID(actual, p.entry)})
+		}
+	}
+
+	if reportFile != "" {
+		err = writeReport(reportFile)
+		if err != nil {
+			return
+		}
+	}
+	printProblems()
+	if len(problems) > 0 {
+		return fmt.Errorf("%d file(s) could not be renamed", len(problems))
+	}
+	return
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// true if the normalization form is chosen per file system with '-form=auto'
+var autoForm = false
+
+// the conventional normalization form of a file system type;
+// macOS file systems use NFD, and almost all others NFC
+func fsForm(fs string) (form norm.Form, known bool) {
+	switch strings.ToLower(fs) {
+	case "apfs", "hfs", "hfsplus":
+		return norm.NFD, true
+	case "":
+		return defaultForm(), false
+	}
+	return norm.NFC, true
+}
+
+// the normalization form for entries in a directory, by its file system
+func formForDir(dir string) norm.Form {
+	form, _ := fsForm(fsType(dir))
+	return form
+}
+
+// the default normalization form of the OS
+func defaultForm() norm.Form {
+	form, _ := parseForm(defaultFormName)
+	return form
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+func init() {
+	commands = append(commands, &command{
+		name:     "bench",
+		synopsis: "",
+		brief:    "measure scan and rename throughput on a generated tree",
+		run:      runBench,
+	})
+}
+
+// names used in generated trees; each contains characters changed by normalization
+var benchNames = []string{"Café", "Ångström", "한국어", "crème brûlée", "Dvořák", "ダウンロード"}
+
+// generate a tree of directories and files; a fraction mix of names is not in the form
+func generateTree(dir string, width, depth, files int, mix float64, form norm.Form) (dirs, total, changes int, err error) {
+	// the opposite form, for names needing normalization
+	other := norm.NFC
+	if form == norm.NFC || form == norm.NFKC {
+		other = norm.NFD
+	}
+	n := 0
+	name := func(ext string) string {
+		n++
+		base := fmt.Sprintf("%s %d%s", benchNames[n%len(benchNames)], n, ext)
+		if float64(n*61%100) < mix*100 { // spread evenly
+			changes++
+			return other.String(base)
+		}
+		return fmt.Sprintf("file %d%s", n, ext)
+	}
+
+	var gen func(dir string, level int) error
+	gen = func(dir string, level int) (err error) {
+		for i := 0; i < files; i++ {
+			f, e := os.Create(filepath.Join(dir, name(".txt")))
+			if e != nil {
+				return e
+			}
+			f.Close()
+			total++
+		}
+		if level >= depth {
+			return
+		}
+		for i := 0; i < width; i++ {
+			sub := filepath.Join(dir, name(""))
+			err = os.Mkdir(sub, 0755)
+			if err != nil {
+				return
+			}
+			dirs++
+			total++
+			err = gen(sub, level+1)
+			if err != nil {
+				return
+			}
+		}
+		return
+	}
+	err = gen(dir, 0)
+	return
+}
+
+func runBench(fs *flag.FlagSet, args []string) (err error) {
+	var (
+		name   = formName
+		width  = 4
+		depth  = 3
+		files  = 100
+		mix    = 0.5
+		base   = ""
+		keep   = false
+		result = func(what string, count int, d time.Duration) {
+			fmt.Printf("%-8s %8d in %8.3fs  (%.0f/s)\n", what, count, d.Seconds(), float64(count)/d.Seconds())
+		}
+	)
+	fs.StringVar(&name, "form", name, "Unicode normalization type. One of NFC, NFD, NFKC, NFKD,\nor WIN, MAC")
+	fs.StringVar(&name, "f", name, "shorthand for '-form'")
+	fs.IntVar(&width, "width", width, "subdirectories per directory")
+	fs.IntVar(&depth, "depth", depth, "depth of subdirectories")
+	fs.IntVar(&files, "files", files, "files per directory")
+	fs.Float64Var(&mix, "mix", mix, "fraction of names needing normalization, 0 to 1")
+	fs.StringVar(&base, "dir", base, "create the tree in this directory, to measure a specific file system\n(default: the temporary directory)")
+	fs.BoolVar(&keep, "keep", keep, "keep the generated tree")
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	formCode, err = parseForm(name)
+	if err != nil {
+		return
+	}
+
+	dir, err := os.MkdirTemp(base, "nufn-bench-")
+	if err != nil {
+		return
+	}
+	if keep {
+		fmt.Printf("tree: %s\n", dir)
+	} else {
+		defer os.RemoveAll(dir)
+	}
+
+	start := time.Now()
+	dirs, total, changes, err := generateTree(dir, width, depth, files, mix, formCode)
+	if err != nil {
+		return
+	}
+	fsName := fsType(dir)
+	if fsName == "" {
+		fsName = "unknown file system"
+	}
+	fmt.Printf("%d directories, %d files, %d names to normalize (%s, %s)\n", dirs, total-dirs, changes, formString(formCode), fsName)
+	result("create", total, time.Since(start))
+
+	// scan without renaming
+	start = time.Now()
+	count := 0
+	err = walk(dir, true, func(path string, fInfo os.FileInfo) error {
+		if !formCode.IsNormalString(fInfo.Name()) {
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	result("scan", total, time.Since(start))
+
+	// rename with the same code as the main command
+	recurse, quiet = true, true
+	start = time.Now()
+	err = process(dir, dir, formCode)
+	if err != nil {
+		return
+	}
+	result("rename", fileCount, time.Since(start))
+
+	if count != changes || fileCount != changes {
+		err = fmt.Errorf("found %d and renamed %d of %d names to normalize", count, fileCount, changes)
+	}
+	return
+}
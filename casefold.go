@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/text/cases"
+)
+
+// case sensitivity of volumes found so far, by volume
+var caseInsensitive = make(map[string]bool)
+
+// a name with the case of its letters swapped, or "" if it has no cased letters
+func swapCase(s string) string {
+	swapped := strings.Map(func(r rune) rune {
+		if u := []rune(strings.ToUpper(string(r))); len(u) == 1 && u[0] != r {
+			return u[0]
+		}
+		if l := []rune(strings.ToLower(string(r))); len(l) == 1 && l[0] != r {
+			return l[0]
+		}
+		return r
+	}, s)
+	if swapped == s {
+		return ""
+	}
+	return swapped
+}
+
+// true if the volume of a file ignores case in names. The volume is probed once, without writing,
+// by looking the file up with the case of its name swapped; until a file with cased letters is
+// found on a volume, it is taken as case-sensitive.
+func isCaseInsensitive(name string, entry os.FileInfo) bool {
+	vol := volumeID(name, entry)
+	if v, ok := caseInsensitive[vol]; ok && vol != "" {
+		return v
+	}
+	swapped := swapCase(filepath.Base(name))
+	if swapped == "" {
+		return false
+	}
+	sInfo, err := fsys.Lstat(filepath.Join(filepath.Dir(name), swapped))
+	v := err == nil && fsys.SameFile(entry, sInfo)
+	if vol != "" {
+		caseInsensitive[vol] = v
+	}
+	return v
+}
+
+// the key of a path among the names planned in a directory; names differing only in case
+// have the same key on case-insensitive volumes
+func planKey(dir, base string, insensitive bool) string {
+	if insensitive {
+		base = cases.Fold().String(base)
+	}
+	return filepath.Join(dir, base)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// a subcommand, invoked as the first command line argument
+type command struct {
+	name     string
+	synopsis string // argument synopsis
+	brief    string // one line description
+	run      func(fs *flag.FlagSet, args []string) error
+}
+
+// subcommands, in display order
+var commands []*command
+
+func findCommand(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// true if a file or directory of the name exists
+func fileExists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+// run a subcommand with its own flag set
+func runCommand(c *command, args []string) (err error) {
+	execName := os.Args[0]
+	fs := flag.NewFlagSet(c.name, flag.ExitOnError)
+	fs.Usage = func() {
+		o := fs.Output()
+		fmt.Fprintln(o)
+		fmt.Fprintf(o, "%s %s: %s\n\n", execName, c.name, c.brief)
+		fmt.Fprintf(o, "Usage: %s %s [option] %s\n\n", execName, c.name, c.synopsis)
+		fs.PrintDefaults()
+		fmt.Fprintln(o)
+	}
+	err = loadConfigOnce()
+	if err != nil {
+		return
+	}
+	return c.run(fs, args)
+}
+
+// print the list of subcommands
+func printCommands() {
+	o := flag.CommandLine.Output()
+	execName := os.Args[0]
+	fmt.Fprintf(o, "Commands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(o, "  %s\n    \t%s\n", strings.TrimSpace(execName+" "+c.name+" "+c.synopsis), c.brief)
+	}
+	fmt.Fprintln(o)
+}
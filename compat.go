@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// true if the form applies compatibility decomposition
+func isCompatForm(form norm.Form) bool {
+	return form == norm.NFKC || form == norm.NFKD
+}
+
+// true if a normalized name differs from the original beyond canonical equivalence
+func changesBeyondCanonical(name, normalized string) bool {
+	return norm.NFD.String(name) != norm.NFD.String(normalized)
+}
+
+// characters of a name changed by compatibility decomposition, as "U+2460 ① -> 1"
+func compatChanges(name string) (l []string) {
+	seen := make(map[rune]bool)
+	for _, r := range name {
+		s := string(r)
+		k := norm.NFKC.String(s)
+		if seen[r] || norm.NFC.String(s) == k {
+			continue
+		}
+		seen[r] = true
+		l = append(l, fmt.Sprintf("U+%04X %s -> %s", r, s, k))
+	}
+	return
+}
+
+// true if a rule of the configuration selects a compatibility form without -max-visual-change
+// in effect for it
+func compatRules() bool {
+	for _, r := range conf.rules {
+		canonical := canonicalOnly
+		if r.canonical != nil {
+			canonical = *r.canonical
+		}
+		if r.form != nil && isCompatForm(*r.form) && !canonical {
+			return true
+		}
+	}
+	return false
+}
+
+// list names that a compatibility form, of the files or of a rule, would change irreversibly,
+// and ask for confirmation
+func confirmCompat(names []string, form norm.Form) (err error) {
+	found := 0
+	for _, n := range names {
+		err = walk(n, recurse, func(path string, fInfo os.FileInfo) error {
+			set, skip := settingsFor(path, fInfo.IsDir(), form)
+			if skip || set.canonical {
+				return nil
+			}
+			f := set.form
+			if !changesBeyondCanonical(fInfo.Name(), f.String(fInfo.Name())) {
+				return nil
+			}
+			found++
+			fmt.Fprintf(stdout, "%s\n  -> %s\n", path, f.String(fInfo.Name()))
+			for _, c := range compatChanges(fInfo.Name()) {
+				fmt.Fprintf(stdout, "     %s\n", c)
+			}
+			return nil
+		})
+		if err != nil {
+			return
+		}
+	}
+	if found == 0 {
+		return
+	}
+
+	fmt.Fprintf(stdout, "%d name(s) above will change beyond canonical equivalence, which cannot be undone by normalization.\n", found)
+	fmt.Fprintf(stdout, "Proceed? [y/N] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return fmt.Errorf("cancelled")
+}
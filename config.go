@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// the configuration file
+type config struct {
+	protect  []string                // additional protected paths
+	profiles map[string][]configLine // settings of named profiles
+	aliases  map[string]string       // normalization form aliases, by upper-cased alias
+	rules    []*rule                 // settings by file patterns, in the order of the file
+}
+
+var (
+	conf       config
+	confLoaded = false
+)
+
+// load the configuration file given with -config, or the default one, once
+func loadConfigOnce() (err error) {
+	if confLoaded {
+		return
+	}
+	confLoaded = true
+	if configFile != "" {
+		return loadConfig(configFile, true)
+	}
+	return loadConfig(defaultConfigFile(), false)
+}
+
+// default path of the configuration file
+func defaultConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "normalize-unicode-filename", "config")
+}
+
+// a line of a configuration file
+type configLine struct {
+	section string // [section] the line belongs to, without brackets
+	key     string
+	value   string
+	lineNo  int
+}
+
+// read an INI-style file: '[section]' headers and 'key = value' lines.
+// Empty lines and lines starting with '#' or ';' are ignored.
+func readConfigLines(filename string) (lines []configLine, err error) {
+	f, err := fsys.Open(filename)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	section := ""
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		l := strings.TrimSpace(sc.Text())
+		switch {
+		case l == "" || strings.HasPrefix(l, "#") || strings.HasPrefix(l, ";"):
+			continue
+		case strings.HasPrefix(l, "[") && strings.HasSuffix(l, "]"):
+			section = strings.TrimSpace(l[1 : len(l)-1])
+			continue
+		}
+		key, value, ok := strings.Cut(l, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected 'key = value'", filename, n)
+		}
+		lines = append(lines, configLine{section, strings.TrimSpace(key), strings.TrimSpace(value), n})
+	}
+	err = sc.Err()
+	return
+}
+
+// load the configuration file; a missing default file is not an error
+func loadConfig(filename string, explicit bool) (err error) {
+	if filename == "" {
+		return
+	}
+	lines, err := readConfigLines(filename)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			err = nil
+		}
+		return
+	}
+	conf.profiles = make(map[string][]configLine)
+	conf.aliases = make(map[string]string)
+	rules := make(map[string]*rule) // by section
+	for _, l := range lines {
+		switch {
+		case l.section == "" && l.key == "protect":
+			conf.protect = append(conf.protect, l.value)
+		case strings.HasPrefix(l.section, "profile "):
+			name := strings.TrimSpace(strings.TrimPrefix(l.section, "profile "))
+			conf.profiles[name] = append(conf.profiles[name], l)
+		case l.section == "alias":
+			switch strings.ToUpper(l.key) {
+			case "NFC", "NFD", "NFKC", "NFKD", "WIN", "MAC", "AUTO": // would hide the built-in name
+				return fmt.Errorf("%s:%d: alias '%s': the name of a built-in normalization form", filename, l.lineNo, l.key)
+			}
+			if _, e := parseForm(l.value); e != nil {
+				return fmt.Errorf("%s:%d: alias '%s': invalid normalization form '%s'", filename, l.lineNo, l.key, l.value)
+			}
+			conf.aliases[strings.ToUpper(l.key)] = l.value
+		case strings.HasPrefix(l.section, "rule "):
+			r := rules[l.section]
+			if r == nil {
+				r = &rule{}
+				r.patterns, err = parseRulePatterns(strings.TrimPrefix(l.section, "rule "))
+				if err != nil {
+					return fmt.Errorf("%s:%d: %w", filename, l.lineNo, err)
+				}
+				rules[l.section] = r
+				conf.rules = append(conf.rules, r)
+			}
+			err = r.set(l.key, l.value)
+			if err != nil {
+				return fmt.Errorf("%s:%d: %w", filename, l.lineNo, err)
+			}
+		default:
+			return fmt.Errorf("%s:%d: unknown setting '%s'", filename, l.lineNo, l.key)
+		}
+	}
+	return
+}
+
+// the long name of an option given by its shorthand, like 'dryrun' for 'd'
+func longName(name string) string {
+	if f := flag.Lookup(name); f != nil {
+		if long, ok := strings.CutPrefix(f.Usage, "shorthand for '-"); ok {
+			return strings.TrimSuffix(long, "'")
+		}
+	}
+	return name
+}
+
+// apply the settings of a named profile to command line flags not given explicitly.
+// 'root' settings are returned as the files to process.
+func applyProfile(name string) (roots []string, err error) {
+	lines, ok := conf.profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("no profile named '%s' in the configuration", name)
+	}
+	explicit := make(map[string]bool) // by long name
+	flag.Visit(func(f *flag.Flag) {
+		explicit[longName(f.Name)] = true
+	})
+	for _, l := range lines {
+		switch l.key {
+		case "root":
+			roots = append(roots, l.value)
+			continue
+		case "profile", "config":
+			return nil, fmt.Errorf("profile '%s': '%s' cannot be set in a profile", name, l.key)
+		}
+		if explicit[longName(l.key)] {
+			continue
+		}
+		if flag.Lookup(l.key) == nil {
+			return nil, fmt.Errorf("profile '%s', line %d: unknown option '%s'", name, l.lineNo, l.key)
+		}
+		err = flag.Set(l.key, l.value)
+		if err != nil {
+			return nil, fmt.Errorf("profile '%s', line %d: %w", name, l.lineNo, err)
+		}
+	}
+	return
+}
+
+// form aliases, as sorted 'alias=FORM' strings
+func aliasList() (l []string) {
+	for a, f := range conf.aliases {
+		l = append(l, strings.ToLower(a)+"="+strings.ToUpper(f))
+	}
+	sort.Strings(l)
+	return
+}
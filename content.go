@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func init() {
+	commands = append(commands, &command{
+		name:     "content",
+		synopsis: "filename [filename...]",
+		brief:    "normalize the Unicode text inside files",
+		run:      runContent,
+	})
+}
+
+// detect a Unicode encoding of a text file by its byte order mark or UTF-8 validity
+func detectEncoding(data []byte) (enc encoding.Encoding, name string, ok bool) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xef, 0xbb, 0xbf}):
+		return unicode.UTF8BOM, "UTF-8 with BOM", true
+	case bytes.HasPrefix(data, []byte{0xff, 0xfe}):
+		return unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM), "UTF-16LE", true
+	case bytes.HasPrefix(data, []byte{0xfe, 0xff}):
+		return unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM), "UTF-16BE", true
+	case utf8.Valid(data):
+		return unicode.UTF8, "UTF-8", true
+	}
+	return nil, "", false
+}
+
+func runContent(fs *flag.FlagSet, args []string) (err error) {
+	var (
+		name      = formName
+		exts      = "txt"
+		recursive = false
+		dry       = false
+		backup    = ""
+		silent    = false
+	)
+	fs.StringVar(&name, "form", name, "Unicode normalization type. One of NFC, NFD, NFKC, NFKD,\nor WIN, MAC")
+	fs.StringVar(&name, "f", name, "shorthand for '-form'")
+	fs.StringVar(&exts, "ext", exts, "comma-separated file extensions to process")
+	fs.BoolVar(&recursive, "r", recursive, "recurse subdirectories")
+	fs.BoolVar(&dry, "d", dry, "shorthand for '-dryrun'")
+	fs.BoolVar(&dry, "dryrun", dry, "dry-run: do not change files; print only")
+	fs.StringVar(&backup, "backup", backup, "keep the original content in a file with this suffix appended, e.g. '.orig'")
+	fs.BoolVar(&silent, "q", silent, "quiet; do not print filenames")
+	fs.BoolVar(&force, "force", force, "process protected locations")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(0)
+	}
+
+	form, err := parseForm(name)
+	if err != nil {
+		return
+	}
+
+	extSet := make(map[string]bool)
+	for _, e := range strings.Split(exts, ",") {
+		e = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(e), "."))
+		if e != "" {
+			extSet[e] = true
+		}
+	}
+
+	names, err := expandArgs(fs.Args())
+	if err != nil {
+		return
+	}
+	if !force {
+		for _, n := range names {
+			err = checkProtected(n)
+			if err != nil {
+				return
+			}
+		}
+	}
+	for _, n := range names {
+		err = walk(n, recursive, func(path string, fInfo os.FileInfo) (err error) {
+			if !fInfo.Mode().IsRegular() || !extSet[strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))] {
+				return
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return
+			}
+			enc, encName, ok := detectEncoding(data)
+			if !ok {
+				warn(path, "skipped; not in a Unicode encoding")
+				return
+			}
+			text, err := enc.NewDecoder().Bytes(data)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			if form.IsNormal(text) {
+				return
+			}
+			out, err := enc.NewEncoder().Bytes(form.Bytes(text))
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+
+			if !silent {
+				fmt.Printf("%s (%s)\n", path, encName)
+			}
+			if dry {
+				return
+			}
+			if backup != "" {
+				err = os.WriteFile(path+backup, data, fInfo.Mode().Perm())
+				if err != nil {
+					return
+				}
+			}
+			return os.WriteFile(path, out, fInfo.Mode().Perm())
+		})
+		if err != nil {
+			return
+		}
+	}
+	return
+}
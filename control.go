@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// true for C0 and C1 control characters, including DEL
+func isControl(r rune) bool {
+	return unicode.IsControl(r)
+}
+
+// true if a name contains control characters
+func hasControlChars(s string) bool {
+	return strings.IndexFunc(s, isControl) >= 0
+}
+
+// replace control characters in a name
+func replaceControlChars(s, replacement string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if isControl(r) {
+			b.WriteString(replacement)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// return an error if a replacement would not make a plain name; a path separator would move
+// the file to another directory
+func checkReplacement(replacement string) error {
+	if strings.ContainsAny(replacement, "/\x00"+string(filepath.Separator)) {
+		return fmt.Errorf("replacement %q contains a path separator or NUL", replacement)
+	}
+	return nil
+}
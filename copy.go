@@ -0,0 +1,160 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+func init() {
+	commands = append(commands, &command{
+		name:     "copy",
+		synopsis: "SRC DST",
+		brief:    "copy files, writing destination names in the normalization form",
+		run:      runCopy,
+	})
+}
+
+// copy a file or a directory tree
+type copier struct {
+	form   norm.Form
+	target *targetOS
+	dry    bool
+	quiet  bool
+	failed int
+
+	written map[string]bool // destination paths written, for dry-run collision checks
+}
+
+// the destination base name of a source name
+func (c *copier) destName(name string) string {
+	n := c.form.String(name)
+	if c.target != nil {
+		n = c.target.sanitize(n)
+	}
+	return n
+}
+
+func (c *copier) copy(src, dst string) (err error) {
+	fInfo, err := os.Lstat(src)
+	if err != nil {
+		return
+	}
+
+	if _, e := os.Lstat(dst); (e == nil || c.written[dst]) && !fInfo.IsDir() {
+		// e.g. two source names normalized to the same name
+		warn(src, "skipped; %s already exists", dst)
+		c.failed++
+		return nil
+	}
+	c.written[dst] = true
+
+	switch {
+	case fInfo.IsDir():
+		if !c.dry {
+			err = os.MkdirAll(dst, fInfo.Mode().Perm()|0700)
+			if err != nil {
+				return
+			}
+		}
+		var d []os.DirEntry
+		d, err = os.ReadDir(src)
+		if err != nil {
+			return
+		}
+		for _, f := range d {
+			from, to := filepath.Join(src, f.Name()), filepath.Join(dst, c.destName(f.Name()))
+			if !c.quiet && filepath.Base(to) != f.Name() {
+				fmt.Printf("%s\n  -> %s\n", from, to)
+			}
+			err = c.copy(from, to)
+			if err != nil {
+				return
+			}
+		}
+		if !c.dry {
+			os.Chtimes(dst, fInfo.ModTime(), fInfo.ModTime())
+		}
+
+	case fInfo.Mode()&os.ModeSymlink != 0:
+		if !c.dry {
+			var target string
+			target, err = os.Readlink(src)
+			if err != nil {
+				return
+			}
+			err = os.Symlink(target, dst)
+		}
+
+	case fInfo.Mode().IsRegular():
+		if !c.dry {
+			err = copyFile(src, dst, fInfo)
+		}
+
+	default:
+		warn(src, "skipped; not a regular file")
+	}
+	return
+}
+
+// copy the content, permission and modification time of a regular file
+func copyFile(src, dst string, fInfo os.FileInfo) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fInfo.Mode().Perm())
+	if err != nil {
+		return
+	}
+	_, err = io.Copy(out, in)
+	if e := out.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(dst)
+		return
+	}
+	return os.Chtimes(dst, fInfo.ModTime(), fInfo.ModTime())
+}
+
+func runCopy(fs *flag.FlagSet, args []string) (err error) {
+	var (
+		name       = formName
+		targetName = ""
+		c          = copier{written: make(map[string]bool)}
+	)
+	fs.StringVar(&name, "form", name, "Unicode normalization type. One of NFC, NFD, NFKC, NFKD,\nor WIN, MAC")
+	fs.StringVar(&name, "f", name, "shorthand for '-form'")
+	fs.StringVar(&targetName, "target", targetName, "also replace characters not allowed on the destination OS with '_':\nwindows, linux or macos")
+	fs.BoolVar(&c.dry, "d", c.dry, "shorthand for '-dryrun'")
+	fs.BoolVar(&c.dry, "dryrun", c.dry, "dry-run: do not copy; print only")
+	fs.BoolVar(&c.quiet, "q", c.quiet, "quiet; do not print renamed filenames")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	c.form, err = parseForm(name)
+	if err != nil {
+		return
+	}
+	if targetName != "" {
+		c.target, err = findTarget(targetName)
+		if err != nil {
+			return
+		}
+	}
+
+	err = c.copy(filepath.Clean(fs.Arg(0)), filepath.Clean(fs.Arg(1)))
+	if err == nil && c.failed > 0 {
+		err = fmt.Errorf("%d file(s) not copied", c.failed)
+	}
+	return
+}
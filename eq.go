@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+func init() {
+	commands = append(commands, &command{
+		name:     "eq",
+		synopsis: "A B",
+		brief:    "report whether two strings are canonically or compatibility equivalent",
+		run:      runEq,
+	})
+}
+
+// describe the first difference of two strings, by code point index
+func firstDiff(a, b string) string {
+	ra, rb := []rune(a), []rune(b)
+	i := 0
+	for i < len(ra) && i < len(rb) && ra[i] == rb[i] {
+		i++
+	}
+	at := func(r []rune) string {
+		if i >= len(r) {
+			return "end of string"
+		}
+		return fmt.Sprintf("U+%04X", r[i])
+	}
+	return fmt.Sprintf("differ at code point %d: %s vs %s", i, at(ra), at(rb))
+}
+
+func runEq(fs *flag.FlagSet, args []string) (err error) {
+	base := false
+	fs.BoolVar(&base, "base", base, "compare the base names of two file paths")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	a, b := fs.Arg(0), fs.Arg(1)
+	if base {
+		a, b = filepath.Base(a), filepath.Base(b)
+	}
+
+	yesno := func(x, y string) string {
+		if x == y {
+			return "yes"
+		}
+		return "no; " + firstDiff(x, y)
+	}
+
+	o := os.Stdout
+	fmt.Fprintf(o, "%q\n%q\n", a, b)
+	fmt.Fprintf(o, "  identical:     %s\n", yesno(a, b))
+	fmt.Fprintf(o, "  canonical:     %s\n", yesno(norm.NFD.String(a), norm.NFD.String(b)))
+	fmt.Fprintf(o, "  compatibility: %s\n", yesno(norm.NFKD.String(a), norm.NFKD.String(b)))
+	return
+}
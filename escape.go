@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// output streams of results and diagnostics; non-ASCII characters are escaped with -ascii-escape
+var (
+	stdout io.Writer = os.Stdout
+	stderr io.Writer = os.Stderr
+)
+
+func setASCIIEscape(on bool) {
+	if on {
+		stdout, stderr = escapeWriter{os.Stdout}, escapeWriter{os.Stderr}
+	}
+}
+
+// escape non-ASCII characters as \uXXXX in UTF-16, as in JSON, and invalid bytes as \xXX
+func escapeNonASCII(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&b, `\x%02X`, s[i])
+		case r < utf8.RuneSelf:
+			b.WriteByte(byte(r))
+		case r > 0xffff:
+			r1, r2 := utf16.EncodeRune(r)
+			fmt.Fprintf(&b, `\u%04x\u%04x`, r1, r2)
+		default:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// a writer escaping non-ASCII characters; each write should hold whole characters
+type escapeWriter struct {
+	w io.Writer
+}
+
+func (e escapeWriter) Write(p []byte) (n int, err error) {
+	_, err = io.WriteString(e.w, escapeNonASCII(string(p)))
+	return len(p), err
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// true if a name needs normalization; the quick check is confirmed only for uncertain names
+func needsChange(name string, form norm.Form) bool {
+	if form.QuickSpanString(name) == len(name) {
+		return false
+	}
+	return !form.IsNormalString(name)
+}
+
+// count names needing normalization in a directory, and its subdirectories if recursive
+func estimateDir(dir string, form norm.Form, total *[2]int) (err error) {
+	d, err := fsys.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	var count [2]int // needs change, clean
+	for _, f := range d {
+		if needsChange(f.Name(), form) {
+			count[0]++
+		} else {
+			count[1]++
+		}
+	}
+	fmt.Fprintf(stdout, "%8d %8d  %s\n", count[0], count[1], dir)
+	total[0] += count[0]
+	total[1] += count[1]
+
+	if !recurse {
+		return
+	}
+	for _, f := range d {
+		if f.IsDir() {
+			err = estimateDir(filepath.Join(dir, f.Name()), form, total)
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// print counts of names needing normalization, per directory.
+// Files given by name are counted under their directories.
+func estimate(names []string, form norm.Form) (err error) {
+	var total [2]int
+	fileCounts := make(map[string]*[2]int) // by directory
+	var fileDirs []string
+	fmt.Fprintf(stdout, "%8s %8s  %s\n", "change", "clean", "directory")
+	for _, name := range names {
+		var fInfo os.FileInfo
+		fInfo, err = fsys.Stat(name)
+		if err != nil {
+			return
+		}
+		if fInfo.IsDir() {
+			err = estimateDir(name, form, &total)
+			if err != nil {
+				return
+			}
+			continue
+		}
+		dir := filepath.Dir(name)
+		count := fileCounts[dir]
+		if count == nil {
+			count = &[2]int{}
+			fileCounts[dir] = count
+			fileDirs = append(fileDirs, dir)
+		}
+		i := 1
+		if needsChange(filepath.Base(name), form) {
+			i = 0
+		}
+		count[i]++
+		total[i]++
+	}
+	for _, dir := range fileDirs {
+		fmt.Fprintf(stdout, "%8d %8d  %s\n", fileCounts[dir][0], fileCounts[dir][1], dir)
+	}
+	fmt.Fprintf(stdout, "%8d %8d  (total)\n", total[0], total[1])
+	return
+}
+
+// count the files a run would rename, as a check before renaming.
+// Names are decided as process does, without renaming or reporting anything.
+func countChanges(names []string, form norm.Form, auto bool) (n int, err error) {
+	saved := planned
+	planned = make(map[string]string) // names taken by the counted renames
+	defer func() { planned = saved }()
+	for _, name := range names {
+		f := form
+		if auto {
+			f = formForDir(filepath.Dir(name))
+		}
+		err = countFile(name, f, auto, &n)
+		if err != nil {
+			return
+		}
+	}
+	if chunkSize > 0 && n > chunkSize { // the rest is left for later runs
+		n = chunkSize
+	}
+	return
+}
+
+// count the renames of a file, and of the files in it if it is a directory searched
+func countFile(name string, form norm.Form, auto bool, n *int) (err error) {
+	entry, err := fsys.Lstat(name)
+	if err != nil {
+		return
+	}
+	fInfo := entry
+	if entry.Mode()&os.ModeSymlink != 0 {
+		if target, e := fsys.Stat(name); e == nil {
+			fInfo = target
+		}
+	}
+	if isQuarantine(name) {
+		return nil
+	}
+	_, fname := filepath.Split(name)
+
+	set, skip := settingsFor(name, fInfo.IsDir(), form)
+	if skip {
+		return nil
+	}
+	newf, _, _ := normalizedName(name, fname, fInfo, entry, set, false)
+	if newf != fname && collision(name, entry, newf) == "" {
+		*n++
+	}
+
+	if !fInfo.IsDir() || !recurse {
+		return nil
+	}
+	if auto {
+		form = formForDir(name)
+	}
+	form, skip, _ = readDirConfig(name, form) // a broken configuration is warned about by process
+	if skip {
+		return nil
+	}
+	if subdirs, ok := unchangedDir(name, fInfo, form); ok {
+		for _, sub := range subdirs {
+			err = countFile(filepath.Join(name, sub), form, auto, n)
+			if err != nil && !os.IsNotExist(err) {
+				return
+			}
+		}
+		return nil
+	}
+	d, e := fsys.ReadDir(name)
+	if e != nil {
+		return nil
+	}
+	for _, f := range d {
+		err = countFile(filepath.Join(name, f.Name()), form, auto, n)
+		if err != nil {
+			return
+		}
+	}
+	return nil
+}
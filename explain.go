@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/unicode/runenames"
+)
+
+// a character as 'U+00E9 LATIN SMALL LETTER E WITH ACUTE'
+func describeRune(r rune) string {
+	return fmt.Sprintf("U+%04X %s", r, runenames.Name(r))
+}
+
+// characters joined with ' + '
+func describeRunes(s string) string {
+	l := make([]string, 0, len(s))
+	for _, r := range s {
+		l = append(l, describeRune(r))
+	}
+	return strings.Join(l, " + ")
+}
+
+// the same characters in any order
+func sameRunes(a, b string) bool {
+	ra, rb := []rune(a), []rune(b)
+	sort.Slice(ra, func(i, j int) bool { return ra[i] < ra[j] })
+	sort.Slice(rb, func(i, j int) bool { return rb[i] < rb[j] })
+	return string(ra) == string(rb)
+}
+
+// explain how normalizing a name changes it, one line for each changed segment.
+// Offsets are in characters of the original name, from 0.
+func explain(name, newf string, form norm.Form) (l []string) {
+	var it norm.Iter
+	it.InitString(form, name)
+	for !it.Done() {
+		start := it.Pos()
+		var out []byte
+		for { // long expansions are returned in parts before the input advances
+			out = append(out, it.Next()...)
+			if it.Pos() > start || it.Done() {
+				break
+			}
+		}
+		seg, src := string(out), name[start:it.Pos()]
+		if seg == src {
+			continue
+		}
+		offset := utf8.RuneCountInString(name[:start])
+		switch {
+		case norm.NFD.String(src) != norm.NFD.String(seg):
+			l = append(l, fmt.Sprintf("%s replaced by its compatibility equivalent %s at offset %d", describeRunes(src), describeRunes(seg), offset))
+		case sameRunes(src, seg):
+			l = append(l, fmt.Sprintf("combining marks reordered to %s at offset %d", describeRunes(seg), offset))
+		case utf8.RuneCountInString(seg) < utf8.RuneCountInString(src):
+			l = append(l, fmt.Sprintf("%s composed into %s at offset %d", describeRunes(src), describeRunes(seg), offset))
+		default:
+			l = append(l, fmt.Sprintf("%s decomposed into %s at offset %d", describeRunes(src), describeRunes(seg), offset))
+		}
+	}
+	if newf != form.String(name) {
+		l = append(l, "invalid or control characters replaced")
+	}
+	return
+}
@@ -0,0 +1,15 @@
+//go:build !unix && !windows
+
+package main
+
+import "os"
+
+// a stable identifier of a file; not available on this OS
+func fileID(name string, fInfo os.FileInfo) string {
+	return ""
+}
+
+// the volume of a file; not available on this OS
+func volumeID(name string, fInfo os.FileInfo) string {
+	return ""
+}
@@ -0,0 +1,27 @@
+//go:build unix
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// a stable identifier of a file: the device and inode numbers
+func fileID(name string, fInfo os.FileInfo) string {
+	st, ok := fInfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d", uint64(st.Dev), uint64(st.Ino))
+}
+
+// the volume of a file: its device number
+func volumeID(name string, fInfo os.FileInfo) string {
+	st, ok := fInfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprint(uint64(st.Dev))
+}
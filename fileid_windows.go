@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// a stable identifier of a file: the volume serial number and the file index
+func fileID(name string, fInfo os.FileInfo) string {
+	p, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return ""
+	}
+	// directories can be opened only with backup semantics; links are not followed
+	h, err := syscall.CreateFile(p, 0, syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS|syscall.FILE_FLAG_OPEN_REPARSE_POINT, 0)
+	if err != nil {
+		return ""
+	}
+	defer syscall.CloseHandle(h)
+	var d syscall.ByHandleFileInformation
+	if syscall.GetFileInformationByHandle(h, &d) != nil {
+		return ""
+	}
+	return fmt.Sprintf("%08x:%08x%08x", d.VolumeSerialNumber, d.FileIndexHigh, d.FileIndexLow)
+}
+
+// the volume of a file: its drive or UNC share
+func volumeID(name string, fInfo os.FileInfo) string {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return ""
+	}
+	return strings.ToUpper(filepath.VolumeName(abs))
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// only files modified after this time are examined, if set
+var newerThan time.Time
+
+// time formats accepted by -newer-than
+var timeFormats = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parse a timestamp, or take the modification time of a reference file
+func parseNewerThan(s string) (t time.Time, err error) {
+	if fInfo, e := os.Stat(s); e == nil {
+		return fInfo.ModTime(), nil
+	}
+	for _, f := range timeFormats {
+		t, err = time.ParseInLocation(f, s, time.Local)
+		if err == nil {
+			return
+		}
+	}
+	return t, fmt.Errorf("'%s' is neither a file nor a timestamp like 2006-01-02 15:04:05", s)
+}
+
+// true if a file should be examined for renaming
+func examine(fInfo os.FileInfo) bool {
+	return newerThan.IsZero() || fInfo.ModTime().After(newerThan)
+}
+
+var (
+	openSet     map[string]bool // files open by other processes, with -skip-open
+	skippedOpen = 0
+)
+
+// true if a file is open by another process
+func isOpen(name string) bool {
+	if openSet == nil {
+		return false
+	}
+	abs, err := filepath.Abs(name)
+	return err == nil && openSet[abs]
+}
+
+var (
+	ownerUID     = -1 // with -owned-by
+	skippedOwner = 0
+)
+
+// the reason why a file should not be renamed by the invoking user, or "" if it may be
+func denied(name string, fInfo os.FileInfo) string {
+	if ownerUID >= 0 {
+		if uid, ok := fileOwner(fInfo); !ok || uid != ownerUID {
+			return "not owned by " + ownedBy
+		}
+	}
+	if writableOnly && !canRename(name, fInfo) {
+		return "no permission to rename"
+	}
+	return ""
+}
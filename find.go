@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+func init() {
+	commands = append(commands, &command{
+		name:     "find",
+		synopsis: "filename [filename...]",
+		brief:    "list files whose names are not in the normalization form, or are invalid",
+		run:      runFind,
+	})
+}
+
+func runFind(fs *flag.FlagSet, args []string) (err error) {
+	var (
+		name      = formName
+		recursive = false
+		print0    = false
+		sensitive = false
+		unstable  = ""
+	)
+	fs.StringVar(&name, "form", name, "Unicode normalization type. One of NFC, NFD, NFKC, NFKD,\nor WIN, MAC")
+	fs.StringVar(&name, "f", name, "shorthand for '-form'")
+	fs.BoolVar(&recursive, "r", recursive, "recurse subdirectories")
+	fs.BoolVar(&print0, "0", print0, "separate filenames with NUL instead of newline")
+	fs.BoolVar(&sensitive, "sensitive", sensitive, "advisory: list all names whose NFC and NFD forms differ (e.g. accented\nletters, Hangul), even if already normalized")
+	fs.StringVar(&unstable, "unstable", unstable, "also list names that runtimes of an older Unicode `version` normalize differently,\ne.g. 6.2 for Java 8 or 5.2 for Python 2.7, and names with unassigned characters")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(0)
+	}
+	if unstable != "" {
+		unstable, err = parseUnicodeVersion(unstable)
+		if err != nil {
+			return
+		}
+	}
+
+	form, err := parseForm(name)
+	if err != nil {
+		return
+	}
+
+	term := "\n"
+	if print0 {
+		term = "\x00"
+	}
+
+	names, err := expandArgs(fs.Args())
+	if err != nil {
+		return
+	}
+	for _, n := range names {
+		err = walk(n, recursive, func(path string, fInfo os.FileInfo) error {
+			switch {
+			case invalidName(fInfo.Name()) != "":
+				warn(path, "%s", invalidName(fInfo.Name()))
+				fmt.Print(path, term)
+			case hasControlChars(fInfo.Name()):
+				warn(path, "name contains control characters")
+				fmt.Print(path, term)
+			case !form.IsNormalString(fInfo.Name()):
+				fmt.Print(path, term)
+			case unstable != "" && unstableChar(fInfo.Name(), unstable) != "":
+				warn(path, "%s", unstableChar(fInfo.Name(), unstable))
+				fmt.Print(path, term)
+			case sensitive && isNormalizationSensitive(fInfo.Name()):
+				fmt.Print(path, term)
+			}
+			return nil
+		})
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// true if a name is represented differently in NFC and NFD, and so at risk of
+// conflicts between systems using different forms
+func isNormalizationSensitive(name string) bool {
+	return norm.NFC.String(name) != norm.NFD.String(name)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+	"text/template"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// fields of a renamed file available to -format templates
+type formatData struct {
+	Old  string // path before renaming
+	New  string // path after renaming
+	Form string // normalization form
+}
+
+var outputTemplate *template.Template
+
+func parseFormat(s string) (err error) {
+	outputTemplate, err = template.New("format").Funcs(template.FuncMap{"csv": csvField}).Parse(s + "\n")
+	return
+}
+
+// quote a field for a CSV line, for plans edited and applied with 'apply-csv'
+func csvField(s string) string {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write([]string{s})
+	w.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// print a renamed file with the -format template
+func printFormatted(w io.Writer, oldName, newName string, form norm.Form) error {
+	return outputTemplate.Execute(w, formatData{
+		Old:  oldName,
+		New:  newName,
+		Form: formString(form),
+	})
+}
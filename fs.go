@@ -0,0 +1,67 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// file system operations used to scan and rename files
+type fileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	Lstat(name string) (os.FileInfo, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	MkdirAll(path string, perm os.FileMode) error
+	Open(name string) (io.ReadCloser, error)
+	OpenFile(name string, flag int, perm os.FileMode) (io.WriteCloser, error) // for writing, as os.OpenFile
+	Readlink(name string) (string, error)
+	Symlink(oldname, newname string) error
+	Glob(pattern string) ([]string, error)
+	SameFile(fi1, fi2 os.FileInfo) bool // true if both describe the same file, as os.SameFile
+}
+
+// the file system of the OS
+type osFS struct{}
+
+func (osFS) Stat(name string) (os.FileInfo, error)        { return os.Stat(name) }
+func (osFS) Lstat(name string) (os.FileInfo, error)       { return os.Lstat(name) }
+func (osFS) ReadDir(name string) ([]os.DirEntry, error)   { return os.ReadDir(name) }
+func (osFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
+func (osFS) Remove(name string) error                     { return os.Remove(name) }
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+func (osFS) Open(name string) (io.ReadCloser, error)      { return os.Open(name) }
+func (osFS) OpenFile(name string, flag int, perm os.FileMode) (io.WriteCloser, error) {
+	return os.OpenFile(name, flag, perm)
+}
+func (osFS) Readlink(name string) (string, error)  { return os.Readlink(name) }
+func (osFS) Symlink(oldname, newname string) error { return os.Symlink(oldname, newname) }
+func (osFS) Glob(pattern string) ([]string, error) { return filepath.Glob(pattern) }
+func (osFS) SameFile(fi1, fi2 os.FileInfo) bool    { return os.SameFile(fi1, fi2) }
+
+// the file system files are processed on; may be replaced to run on other file systems
+var fsys fileSystem = osFS{}
+
+// read a whole file, as os.ReadFile
+func readFile(name string) (data []byte, err error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// write a whole file, as os.WriteFile
+func writeFile(name string, data []byte, perm os.FileMode) (err error) {
+	f, err := fsys.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return
+	}
+	_, err = f.Write(data)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	return
+}
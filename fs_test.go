@@ -0,0 +1,237 @@
+package main
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// a file of memFS
+type memFile struct {
+	name string // the path as created or renamed, with the case of its letters
+	id   int
+	dir  bool
+	data string
+}
+
+func (f *memFile) Name() string       { return filepath.Base(f.name) }
+func (f *memFile) Size() int64        { return int64(len(f.data)) }
+func (f *memFile) ModTime() time.Time { return time.Time{} }
+func (f *memFile) IsDir() bool        { return f.dir }
+func (f *memFile) Sys() interface{}   { return nil }
+func (f *memFile) Mode() os.FileMode {
+	if f.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// an in-memory file system that ignores case in names, but not normalization
+type memFS struct {
+	files  map[string]*memFile // by key
+	nextID int
+}
+
+func newMemFS() *memFS {
+	m := &memFS{files: make(map[string]*memFile)}
+	m.add(string(filepath.Separator), true, "")
+	return m
+}
+
+func (m *memFS) key(name string) string {
+	return strings.ToLower(filepath.Clean(name))
+}
+
+func (m *memFS) add(name string, dir bool, data string) {
+	m.nextID++
+	m.files[m.key(name)] = &memFile{name: filepath.Clean(name), id: m.nextID, dir: dir, data: data}
+}
+
+func (m *memFS) Stat(name string) (os.FileInfo, error) { return m.Lstat(name) }
+
+func (m *memFS) Lstat(name string) (os.FileInfo, error) {
+	f, ok := m.files[m.key(name)]
+	if !ok {
+		return nil, &fs.PathError{Op: "lstat", Path: name, Err: fs.ErrNotExist}
+	}
+	return f, nil
+}
+
+func (m *memFS) ReadDir(name string) (l []os.DirEntry, err error) {
+	dir := m.key(name)
+	for k, f := range m.files {
+		if k != dir && filepath.Dir(k) == dir {
+			l = append(l, fs.FileInfoToDirEntry(f))
+		}
+	}
+	sort.Slice(l, func(i, j int) bool { return l[i].Name() < l[j].Name() })
+	return
+}
+
+func (m *memFS) Rename(oldpath, newpath string) error {
+	f, ok := m.files[m.key(oldpath)]
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrNotExist}
+	}
+	if other, ok := m.files[m.key(newpath)]; ok && other != f {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrExist}
+	}
+	oldKey := m.key(oldpath)
+	for k, c := range m.files {
+		if k == oldKey || strings.HasPrefix(k, oldKey+string(filepath.Separator)) {
+			delete(m.files, k)
+			c.name = filepath.Clean(newpath) + c.name[len(filepath.Clean(oldpath)):]
+			m.files[m.key(c.name)] = c
+		}
+	}
+	return nil
+}
+
+func (m *memFS) Remove(name string) error {
+	if _, ok := m.files[m.key(name)]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(m.files, m.key(name))
+	return nil
+}
+
+func (m *memFS) MkdirAll(path string, perm os.FileMode) error {
+	for p := filepath.Clean(path); ; p = filepath.Dir(p) {
+		if _, ok := m.files[m.key(p)]; !ok {
+			m.add(p, true, "")
+		}
+		if filepath.Dir(p) == p {
+			return nil
+		}
+	}
+}
+
+// a file of memFS open for writing
+type memWriter struct {
+	m    *memFS
+	name string
+	strings.Builder
+}
+
+func (w *memWriter) Close() error {
+	w.m.add(w.name, false, w.String())
+	return nil
+}
+
+func (m *memFS) OpenFile(name string, flag int, perm os.FileMode) (io.WriteCloser, error) {
+	w := &memWriter{m: m, name: name}
+	if f, ok := m.files[m.key(name)]; ok && flag&os.O_APPEND != 0 {
+		w.WriteString(f.data)
+	}
+	return w, nil
+}
+
+func (m *memFS) Readlink(name string) (string, error) {
+	return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+}
+
+func (m *memFS) Symlink(oldname, newname string) error {
+	return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: fs.ErrInvalid}
+}
+
+func (m *memFS) Open(name string) (io.ReadCloser, error) {
+	f, ok := m.files[m.key(name)]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return io.NopCloser(strings.NewReader(f.data)), nil
+}
+
+func (m *memFS) Glob(pattern string) ([]string, error) {
+	if _, err := m.Lstat(pattern); err != nil {
+		return nil, nil
+	}
+	return []string{pattern}, nil
+}
+
+func (m *memFS) SameFile(fi1, fi2 os.FileInfo) bool {
+	f1, ok1 := fi1.(*memFile)
+	f2, ok2 := fi2.(*memFile)
+	return ok1 && ok2 && f1.id == f2.id
+}
+
+func TestProcessCaseInsensitive(t *testing.T) {
+	m := newMemFS()
+	dir := filepath.Join(string(filepath.Separator), "d")
+	m.add(dir, true, "")
+	m.add(filepath.Join(dir, "Cafe\u0301.txt"), false, "a")
+	m.add(filepath.Join(dir, "CAF\u00c9.TXT"), false, "b") // the normalized name in another case
+	m.add(filepath.Join(dir, "nai\u0308ve.txt"), false, "c")
+	m.add(filepath.Join(dir, "Re\u0301sume\u0301"), true, "")
+	m.add(filepath.Join(dir, "Re\u0301sume\u0301", "e\u0301.txt"), false, "d")
+
+	defer func(f fileSystem, r, q bool) {
+		fsys, recurse, quiet = f, r, q
+		problems = nil
+		planned = make(map[string]string)
+		caseInsensitive = make(map[string]bool)
+	}(fsys, recurse, quiet)
+	fsys, recurse, quiet = m, true, true
+
+	err := process(dir, dir, norm.NFC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := m.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range d {
+		got = append(got, f.Name())
+	}
+	want := []string{"CAF\u00c9.TXT", "Cafe\u0301.txt", "R\u00e9sum\u00e9", "na\u00efve.txt"}
+	sort.Strings(want)
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("names: got %q, want %q", got, want)
+	}
+	if _, err := m.Lstat(filepath.Join(dir, "R\u00e9sum\u00e9", "\u00e9.txt")); err != nil {
+		t.Errorf("file in a renamed directory: %v", err)
+	}
+	if len(problems) != 1 || !strings.Contains(problems[0].Reason, "already exists") {
+		t.Errorf("problems: got %v, want one for the existing name", problems)
+	}
+}
+
+func TestPruneInMemory(t *testing.T) {
+	m := newMemFS()
+	dir := filepath.Join(string(filepath.Separator), "d")
+	m.add(dir, true, "")
+	m.add(filepath.Join(dir, "e\u0301.txt"), false, "same")
+	m.add(filepath.Join(dir, "\u00e9.txt"), false, "same")
+
+	defer func(f fileSystem, r, q, p bool) {
+		fsys, recurse, quiet, pruneIdentical = f, r, q, p
+		problems, pruned = nil, nil
+		planned = make(map[string]string)
+		caseInsensitive = make(map[string]bool)
+	}(fsys, recurse, quiet, pruneIdentical)
+	fsys, recurse, quiet, pruneIdentical = m, true, true, true
+
+	err := process(dir, dir, norm.NFC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Lstat(filepath.Join(dir, "e\u0301.txt")); err == nil {
+		t.Errorf("duplicate not removed")
+	}
+	if _, err := m.Lstat(filepath.Join(dir, "\u00e9.txt")); err != nil {
+		t.Errorf("file with the normalized name: %v", err)
+	}
+	if len(pruned) != 1 {
+		t.Errorf("pruned: got %v, want one entry", pruned)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import "syscall"
+
+// file system magic numbers, from statfs(2)
+var fsMagic = map[uint32]string{
+	0xef53:     "ext4",
+	0x9123683e: "btrfs",
+	0x58465342: "xfs",
+	0x2fc12fc1: "zfs",
+	0x01021994: "tmpfs",
+	0x794c7630: "overlayfs",
+	0x5346544e: "ntfs",
+	0x7366746e: "ntfs3",
+	0x65735546: "fuse",
+	0x4d44:     "vfat",
+	0x2011bab0: "exfat",
+	0x482b:     "hfsplus",
+	0x4244:     "hfs",
+	0x6969:     "nfs",
+	0x517b:     "smb",
+	0xff534d42: "cifs",
+	0xfe534d42: "smb2",
+	0x9660:     "iso9660",
+	0x15013346: "udf",
+}
+
+// the type of the file system containing a path
+func fsType(path string) string {
+	var st syscall.Statfs_t
+	if syscall.Statfs(path, &st) != nil {
+		return ""
+	}
+	return fsMagic[uint32(st.Type)]
+}
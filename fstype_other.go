@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package main
+
+// the type of the file system containing a path; not detected on this OS
+func fsType(path string) string {
+	return ""
+}
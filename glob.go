@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode"
+)
+
+// expand the first top-level {a,b,...} group of a pattern, recursively.
+// Groups without a comma are kept literally.
+func expandBraces(pattern string) []string {
+	depth, start := 0, -1
+	var commas []int
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '{':
+			if depth == 0 {
+				start, commas = i, nil
+			}
+			depth++
+		case ',':
+			if depth == 1 {
+				commas = append(commas, i)
+			}
+		case '}':
+			if depth == 0 {
+				continue
+			}
+			depth--
+			if depth > 0 || len(commas) == 0 {
+				continue
+			}
+			prefix, suffix := pattern[:start], pattern[i+1:]
+			var l []string
+			from := start + 1
+			for _, c := range append(commas, i) {
+				l = append(l, expandBraces(prefix+pattern[from:c]+suffix)...)
+				from = c + 1
+			}
+			return l
+		}
+	}
+	return []string{pattern}
+}
+
+// true if a path is inside a directory
+func isInside(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// remove paths inside other paths of the list, which are reached by recursion anyway
+func dropNested(names []string) (l []string) {
+	abs := make([]string, len(names))
+	for i, n := range names {
+		abs[i], _ = filepath.Abs(n)
+	}
+	for i, n := range names {
+		nested := false
+		for j := range names {
+			if i != j && isInside(abs[i], abs[j]) {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			l = append(l, n)
+		}
+	}
+	return
+}
+
+// make the last component of a glob pattern match letters case-insensitively, by turning letters
+// outside of character classes into classes of both cases, e.g. "*.jpg" to "*.[jJ][pP][gG]".
+// Directories and the volume name are left as they are.
+func caseFoldPattern(pattern string) string {
+	dir, base := filepath.Split(pattern)
+	var b strings.Builder
+	b.WriteString(dir)
+	inClass, escaped := false, false
+	for _, r := range base {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && runtime.GOOS != "windows":
+			escaped = true
+		case inClass:
+			inClass = r != ']'
+		case r == '[':
+			inClass = true
+		case unicode.IsLetter(r):
+			lower, upper := unicode.ToLower(r), unicode.ToUpper(r)
+			if lower != upper {
+				b.WriteString("[" + string(lower) + string(upper) + "]")
+				continue
+			}
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// replace directories in a list with their entries
+func expandChildren(names []string) (l []string, err error) {
+	for _, name := range names {
+		var fInfo os.FileInfo
+		fInfo, err = fsys.Stat(name)
+		if err != nil {
+			return
+		}
+		if !fInfo.IsDir() {
+			l = append(l, name)
+			continue
+		}
+		var d []os.DirEntry
+		d, err = fsys.ReadDir(name)
+		if err != nil {
+			return
+		}
+		for _, f := range d {
+			l = append(l, filepath.Join(name, f.Name()))
+		}
+	}
+	return
+}
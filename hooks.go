@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// split a command line into words, honoring single and double quotes and backslash escapes
+// in the way of POSIX shells; no other shell expansion is done
+func splitCommand(s string) (words []string, err error) {
+	var (
+		b      strings.Builder
+		inWord = false
+		quote  = rune(0)
+		escape = false
+	)
+	for _, r := range s {
+		switch {
+		case escape:
+			if quote == '"' && !strings.ContainsRune("\"\\$`", r) {
+				b.WriteRune('\\') // kept as in POSIX shells
+			}
+			b.WriteRune(r)
+			escape = false
+		case r == '\\' && quote != '\'':
+			escape, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				b.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, b.String())
+				b.Reset()
+				inWord = false
+			}
+		default:
+			b.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escape {
+		return nil, fmt.Errorf("unterminated quote or escape in command '%s'", s)
+	}
+	if inWord {
+		words = append(words, b.String())
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	return
+}
+
+// the command run after each rename, split into words
+var execWords []string
+
+// run the per-file command with {old} and {new} replaced by file paths.
+// Each word is passed as a single argument, so paths need no quoting.
+func runExecHook(oldName, newName string) {
+	if execWords == nil {
+		return
+	}
+	r := strings.NewReplacer("{old}", oldName, "{new}", newName)
+	args := make([]string, len(execWords))
+	for i, w := range execWords {
+		args[i] = r.Replace(w)
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		warn(newName, "exec: %v", err)
+	}
+}
+
+// summary of a run, given to the on-complete command
+type runSummary struct {
+	Version   int    `json:"version"` // same as the report version
+	Form      string `json:"form"`
+	DryRun    bool   `json:"dryrun"`
+	Processed int    `json:"processed"`
+	Renamed   int    `json:"renamed"`
+	Report    string `json:"report,omitempty"` // absolute path of the JSON report
+	Error     string `json:"error,omitempty"`
+}
+
+// run the on-complete command with the summary as its standard input
+func runCompleteHook(command string, runErr error) (err error) {
+	words, err := splitCommand(command)
+	if err != nil {
+		return
+	}
+
+	sum := runSummary{
+		Version:   reportVersion,
+		Form:      runFormName(),
+		DryRun:    dryrun,
+		Processed: scanCount,
+		Renamed:   fileCount,
+	}
+	if reportFile != "" {
+		sum.Report, _ = filepath.Abs(reportFile)
+	}
+	if runErr != nil {
+		sum.Error = runErr.Error()
+	}
+	data, err := json.Marshal(sum)
+	if err != nil {
+		return
+	}
+
+	cmd := exec.Command(words[0], words[1:]...)
+	cmd.Stdin = bytes.NewReader(append(data, '\n'))
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	return cmd.Run()
+}
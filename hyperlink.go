@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// true if printed paths are wrapped in OSC 8 terminal hyperlinks
+var hyperlinks = false
+
+// decide whether to emit hyperlinks: "always", "never", or "auto" for terminals
+func setHyperlinks(mode string) error {
+	switch mode {
+	case "always":
+		hyperlinks = true
+	case "never", "":
+		hyperlinks = false
+	case "auto":
+		fInfo, err := os.Stdout.Stat()
+		hyperlinks = err == nil && fInfo.Mode()&os.ModeCharDevice != 0 &&
+			os.Getenv("TERM") != "dumb"
+	default:
+		return fmt.Errorf("invalid hyperlink mode '%s'; one of auto, always, never", mode)
+	}
+	return nil
+}
+
+// wrap a printed path in an OSC 8 hyperlink to the file:// URL of target, if enabled
+func linkPath(name, target string) string {
+	if !hyperlinks {
+		return name
+	}
+	abs, err := filepath.Abs(target)
+	if err != nil {
+		return name
+	}
+	host, _ := os.Hostname()
+	u := url.URL{Scheme: "file", Host: host, Path: filepath.ToSlash(abs)}
+	if filepath.VolumeName(abs) != "" { // e.g. C:\ on Windows
+		u.Path = "/" + u.Path
+	}
+	return "\x1b]8;;" + u.String() + "\x1b\\" + name + "\x1b]8;;\x1b\\"
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"golang.org/x/text/unicode/runenames"
+)
+
+func init() {
+	commands = append(commands, &command{
+		name:     "inspect",
+		synopsis: "string [string...]",
+		brief:    "print code points and normalized forms of strings",
+		run:      runInspect,
+	})
+}
+
+// code points of a string, in U+XXXX notation
+func codePoints(s string) string {
+	l := make([]string, 0, len(s))
+	for _, r := range s {
+		l = append(l, fmt.Sprintf("U+%04X", r))
+	}
+	return strings.Join(l, " ")
+}
+
+func runInspect(fs *flag.FlagSet, args []string) (err error) {
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(0)
+	}
+
+	o := os.Stdout
+	for i, s := range fs.Args() {
+		if i > 0 {
+			fmt.Fprintln(o)
+		}
+		fmt.Fprintf(o, "%q\n", s)
+
+		// code points of the input
+		for _, r := range s {
+			fmt.Fprintf(o, "  U+%04X  %s\n", r, runenames.Name(r))
+		}
+		fmt.Fprintln(o)
+
+		// normalized forms side by side; '=' marks forms identical to the input
+		w := tabwriter.NewWriter(o, 0, 8, 2, ' ', 0)
+		for _, f := range forms {
+			n := f.form.String(s)
+			same := " "
+			if n == s {
+				same = "="
+			}
+			fmt.Fprintf(w, "  %s %s\t%d bytes\t%s\t%q\n", same, f.name, len(n), codePoints(n), n)
+		}
+		w.Flush()
+	}
+	return
+}
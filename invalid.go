@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// true for Unicode noncharacters: U+FDD0..U+FDEF, and the last two code points of each plane
+func isNonchar(r rune) bool {
+	return (r >= 0xfdd0 && r <= 0xfdef) || r&0xfffe == 0xfffe
+}
+
+// describe the first invalid part of a name, or return "" if there is none.
+// Unpaired UTF-16 surrogates, as left by some Windows applications, appear
+// in WTF-8 form (0xED 0xA0-0xBF ...) on other systems.
+func invalidName(s string) string {
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size <= 1 {
+			if i+2 < len(s) && s[i] == 0xed && s[i+1] >= 0xa0 && s[i+1] <= 0xbf {
+				u := rune(s[i+1]&0x3f)<<6 | rune(s[i+2]&0x3f) | 0xd000
+				return fmt.Sprintf("unpaired surrogate U+%04X at byte %d", u, i)
+			}
+			return fmt.Sprintf("invalid UTF-8 byte 0x%02X at byte %d", s[i], i)
+		}
+		if isNonchar(r) {
+			return fmt.Sprintf("noncharacter U+%04X at byte %d", r, i)
+		}
+		i += size
+	}
+	return ""
+}
+
+// replace invalid byte sequences, including surrogates, and noncharacters in a name
+func replaceInvalid(s, replacement string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size <= 1:
+			// a WTF-8 surrogate is replaced as a whole
+			if i+2 < len(s) && s[i] == 0xed && s[i+1] >= 0xa0 && s[i+1] <= 0xbf {
+				size = 3
+			}
+			b.WriteString(replacement)
+		case isNonchar(r):
+			b.WriteString(replacement)
+		default:
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// return an error if a replacement of invalid parts would not make a plain, valid name
+func checkInvalidReplacement(replacement string) error {
+	if problem := invalidName(replacement); problem != "" {
+		return fmt.Errorf("replacement %q: %s", replacement, problem)
+	}
+	return checkReplacement(replacement)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// a scanned file in the inventory
+type inventoryEntry struct {
+	Path   string    `json:"path"`          // path before renaming, as in reports
+	New    string    `json:"new,omitempty"` // path after renaming, if renamed
+	Status string    `json:"status"`
+	Dir    bool      `json:"dir"`
+	Size   int64     `json:"size"`
+	MTime  time.Time `json:"mtime"`
+}
+
+// status of scanned files in the inventory
+const (
+	statusNormal      = "normal"      // already in the normalization form
+	statusRenamed     = "renamed"     // renamed, or to be renamed in a dry run
+	statusUnexamined  = "unexamined"  // only searched, with -newer-than or -skip-open
+	statusExcluded    = "excluded"    // skipped by a rule
+	statusInvalid     = "invalid"     // invalid name, not renamed
+	statusControl     = "control"     // name with control characters
+	statusSkipped     = "skipped"     // not renamed, for -max-visual-change, -owned-by or -writable-only
+	statusCollision   = "collision"   // not renamed, colliding with another file
+	statusDeferred    = "deferred"    // left for a later run with -chunk
+	statusFailed      = "failed"      // renaming failed
+	statusQuarantined = "quarantined" // moved to the quarantine directory
+	statusPruned      = "pruned"      // removed, identical to the file with its normalized name
+)
+
+var (
+	inventoryFile *os.File
+	inventoryBuf  *bufio.Writer
+	inventoryCSV  *csv.Writer // for .csv files; JSON lines otherwise
+)
+
+// create the inventory file: CSV for .csv files, JSON lines otherwise
+func openInventory(filename string) (err error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".sqlite", ".sqlite3", ".db":
+		return fmt.Errorf("%s: SQLite is not supported; write a .csv file and import it, e.g. with sqlite3 '.import --csv'", filename)
+	}
+	inventoryFile, err = os.Create(filename)
+	if err != nil {
+		return
+	}
+	inventoryBuf = bufio.NewWriter(inventoryFile)
+	if strings.EqualFold(filepath.Ext(filename), ".csv") {
+		inventoryCSV = csv.NewWriter(inventoryBuf)
+		inventoryCSV.Write([]string{"path", "new", "status", "dir", "size", "mtime"})
+	}
+	return
+}
+
+// add a scanned file to the inventory
+func recordInventory(oldName, newName, status string, fInfo os.FileInfo) {
+	if inventoryFile == nil {
+		return
+	}
+	e := inventoryEntry{Status: status, Dir: fInfo.IsDir(), Size: fInfo.Size(), MTime: fInfo.ModTime()}
+	e.Path, _ = reportPath(oldName)
+	if status == statusRenamed {
+		e.New, _ = reportPath(newName)
+	}
+	if inventoryCSV != nil {
+		inventoryCSV.Write([]string{e.Path, e.New, e.Status, strconv.FormatBool(e.Dir),
+			strconv.FormatInt(e.Size, 10), e.MTime.Format(time.RFC3339Nano)})
+		return
+	}
+	data, _ := json.Marshal(e)
+	inventoryBuf.Write(append(data, '\n'))
+}
+
+func closeInventory() (err error) {
+	if inventoryFile == nil {
+		return
+	}
+	if inventoryCSV != nil {
+		inventoryCSV.Flush()
+	}
+	err = inventoryBuf.Flush()
+	if e := inventoryFile.Close(); err == nil {
+		err = e
+	}
+	return
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// naming constraints of legacy software and media, checked with -compat
+var legacyTargets = []targetOS{
+	{"smb1", checkSMB1, nil},
+	{"fat32", checkFAT32, nil},
+	{"joliet", checkJoliet, nil},
+}
+
+func findLegacyTarget(name string) (*targetOS, error) {
+	for i := range legacyTargets {
+		if strings.EqualFold(legacyTargets[i].name, name) {
+			return &legacyTargets[i], nil
+		}
+	}
+	return nil, fmt.Errorf("invalid compatibility target '%s'; one of smb1, fat32, joliet", name)
+}
+
+// true if a name has characters beyond the Basic Multilingual Plane, which UCS-2 cannot represent
+func hasNonBMP(s string) bool {
+	for _, r := range s {
+		if r > 0xffff {
+			return true
+		}
+	}
+	return false
+}
+
+// SMB1 clients negotiating names in UCS-2, with Windows naming rules
+func checkSMB1(base, path string) (l []string) {
+	if strings.ContainsAny(base, `<>:"/\|?*`) {
+		l = append(l, "invalid character")
+	}
+	if hasNonBMP(base) {
+		l = append(l, "character outside the BMP (UCS-2)")
+	}
+	if utf16Len(base) > 255 {
+		l = append(l, "name longer than 255 characters")
+	}
+	return
+}
+
+// long file names of FAT32, stored in UCS-2 by many implementations
+func checkFAT32(base, path string) (l []string) {
+	if strings.ContainsAny(base, `<>:"/\|?*`) {
+		l = append(l, "invalid character")
+	}
+	if hasControlChars(base) {
+		l = append(l, "control character")
+	}
+	if strings.HasSuffix(base, ".") || strings.HasSuffix(base, " ") {
+		l = append(l, "trailing dot or space")
+	}
+	if hasNonBMP(base) {
+		l = append(l, "character outside the BMP (UCS-2)")
+	}
+	if utf16Len(base) > 255 {
+		l = append(l, "name longer than 255 characters")
+	}
+	return
+}
+
+// Joliet extension of ISO 9660, in UCS-2 with at most 64 characters per name
+func checkJoliet(base, path string) (l []string) {
+	if strings.ContainsAny(base, `*/:;?\`) {
+		l = append(l, "invalid character")
+	}
+	if hasControlChars(base) {
+		l = append(l, "control character")
+	}
+	if hasNonBMP(base) {
+		l = append(l, "character outside the BMP (UCS-2)")
+	}
+	if utf16Len(base) > 64 {
+		l = append(l, "name longer than 64 characters")
+	}
+	return
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// path length limits
+type lengthLimits struct {
+	name    int // bytes of a path component
+	path    int // bytes of a path
+	winPath int // UTF-16 characters of a path on Windows
+}
+
+var limits = lengthLimits{name: 255, path: 4096, winPath: 260}
+
+// parse limits in 'name=255,path=4096,win=260' form; omitted limits are kept
+func parseLimits(s string) (err error) {
+	for _, kv := range strings.Split(s, ",") {
+		if strings.TrimSpace(kv) == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(kv, "=")
+		n, e := strconv.Atoi(strings.TrimSpace(v))
+		if e != nil || n <= 0 {
+			return fmt.Errorf("invalid length limit '%s'", kv)
+		}
+		switch strings.TrimSpace(k) {
+		case "name":
+			limits.name = n
+		case "path":
+			limits.path = n
+		case "win":
+			limits.winPath = n
+		default:
+			return fmt.Errorf("invalid length limit '%s'; one of name, path, win", kv)
+		}
+	}
+	return
+}
+
+// print path lengths of a file before and after normalization, to the writer the file is printed to.
+// The file name is not printed again if it has just been printed.
+func printLengths(w io.Writer, oldName, newName string, printed bool) {
+	oldBase, newBase := filepath.Base(oldName), filepath.Base(newName)
+	// the limits apply to full paths
+	oldPath, newPath := oldName, newName
+	if abs, err := filepath.Abs(oldName); err == nil {
+		oldPath = abs
+	}
+	if abs, err := filepath.Abs(newName); err == nil {
+		newPath = abs
+	}
+
+	var over []string
+	if len(newBase) > limits.name {
+		over = append(over, fmt.Sprintf("name > %d bytes", limits.name))
+	}
+	if len(newPath) > limits.path {
+		over = append(over, fmt.Sprintf("path > %d bytes", limits.path))
+	}
+	if utf16Len(newPath) > limits.winPath {
+		over = append(over, fmt.Sprintf("path > %d characters", limits.winPath))
+	}
+	exceeds := ""
+	if len(over) > 0 {
+		exceeds = "; exceeds " + strings.Join(over, ", ")
+	}
+
+	if !printed {
+		fmt.Fprintf(w, "%s\n", newName)
+	}
+	fmt.Fprintf(w, "  length: name %d -> %d bytes, path %d -> %d bytes, %d -> %d characters%s\n",
+		len(oldBase), len(newBase),
+		len(oldPath), len(newPath),
+		utf16Len(oldPath), utf16Len(newPath),
+		exceeds)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -13,17 +14,64 @@ import (
 
 // command line arguments
 var (
-	formName  string = "NFC"
-	recurse          = false
-	quiet            = false
-	dryrun           = false
-	printBoth        = false
+	formName           string = "NFC"
+	recurse                   = false
+	quiet                     = false
+	dryrun                    = false
+	printBoth                 = false
+	showVersion               = false
+	fixRefTypes               = ""
+	fixSymlinks               = false
+	reportFile                = ""
+	configFile                = ""
+	force                     = false
+	targetName                = ""
+	showLengths               = false
+	limitSpec                 = ""
+	execCommand               = ""
+	onComplete                = ""
+	outputFormat              = ""
+	assumeYes                 = false
+	canonicalOnly             = false
+	estimateOnly              = false
+	newerThanSpec             = ""
+	stateFileName             = ""
+	profileName               = ""
+	controlReplacement        = ""
+	ignoreCase                = false
+	children                  = false
+	hyperlinkMode             = "never"
+	showSchema                = false
+	invalidReplacement        = ""
+	compareFile               = ""
+	skipOpen                  = false
+	rootList                  = rootFlag{}
+	ownedBy                   = ""
+	writableOnly              = false
+	chunkSize                 = 0
+	planFile                  = ""
+	maxRenames                = 0
+	compatTargets             = ""
+	sortOrder                 = "none"
+	warningsOutput            = "text"
+	absPaths                  = false
+	relativeTo                = ""
+	quarantineDir             = ""
+	retryFailed               = ""
+	inventoryName             = ""
+	explainChanges            = false
+	asciiEscape               = false
+	pruneIdentical            = false
 )
 
 // runtime variables
 var (
-	formCode  norm.Form
-	fileCount = 0
+	formCode        norm.Form
+	defaultFormName string // normalization form of the OS
+	runForm         string // name of the normalization form given with -form
+	fileCount       = 0    // number of renamed files
+	scanCount       = 0    // number of processed files
+	leftCount       = 0    // number of files left unexamined or needing a change
 
 	dirFixed = make(map[string]string)
 
@@ -49,50 +97,308 @@ Print possible filenames for NFKD form, without changing filenames:
 `
 )
 
+// all normalization forms, in display order
+var forms = []struct {
+	name string
+	form norm.Form
+}{
+	{"NFC", norm.NFC},
+	{"NFD", norm.NFD},
+	{"NFKC", norm.NFKC},
+	{"NFKD", norm.NFKD},
+}
+
+// parse a normalization form name, or an alias defined in the configuration
+func parseForm(name string) (form norm.Form, err error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if alias, ok := conf.aliases[name]; ok {
+		name = strings.ToUpper(alias)
+	}
+	switch name {
+	case "NFC", "WIN": // Canonical equivalence, Composing
+		form = norm.NFC
+	case "NFD", "MAC": // Canonical equivalence, Decomposing
+		form = norm.NFD
+
+	case "NFKC": // Kompatibility equivalence, Composing
+		form = norm.NFKC
+	case "NFKD": // Kompatibility equivalence, Decomposing
+		form = norm.NFKD
+	default:
+		err = fmt.Errorf("invalid normalization form")
+	}
+	return
+}
+
+// the name of the normalization form of the run
+func runFormName() string {
+	if runForm == "" {
+		return formString(formCode)
+	}
+	return runForm
+}
+
+// the name of a normalization form
+func formString(form norm.Form) string {
+	for _, f := range forms {
+		if f.form == form {
+			return f.name
+		}
+	}
+	return "?"
+}
+
+// print a warning about a file
+func warn(name string, format string, a ...interface{}) {
+	diag("warning", name, fmt.Sprintf(format, a...))
+}
+
 func normalize(s string) string {
 	return formCode.String(s)
 }
 
-func process(originalName string) (err error) {
+// settings of a file, which rules in the configuration may override
+type fileSettings struct {
+	form        norm.Form
+	canonical   bool   // -max-visual-change
+	controlRepl string // -replace-control
+	invalidRepl string // -replace-invalid
+}
+
+// the settings of a file: the command line options, overridden by the first rule matching the file.
+// skip is true for a file excluded by the rule.
+func settingsFor(name string, isDir bool, form norm.Form) (set fileSettings, skip bool) {
+	set = fileSettings{form, canonicalOnly, controlReplacement, invalidReplacement}
+	r := matchRule(name, isDir)
+	if r == nil {
+		return
+	}
+	if r.skip {
+		return set, true
+	}
+	if r.form != nil {
+		set.form = *r.form
+	}
+	if r.canonical != nil {
+		set.canonical = *r.canonical
+	}
+	if r.control != nil {
+		set.controlRepl = *r.control
+	}
+	if r.invalid != nil {
+		set.invalidRepl = *r.invalid
+	}
+	return
+}
+
+// the name a file is to be renamed to, and its status for the inventory; a name that cannot be
+// normalized is left unchanged, with warnings if report is set. An invalid name to be moved to the
+// quarantine directory gets statusQuarantined and the problem with the name.
+func normalizedName(name, fname string, fInfo, entry os.FileInfo, set fileSettings, report bool) (newf, status, problem string) {
+	if !examine(fInfo) { // not modified recently; only descended into
+		return fname, statusUnexamined, ""
+	}
+	if !fInfo.IsDir() && isOpen(name) {
+		if report {
+			warn(name, "skipped; open by another process")
+			skippedOpen++
+		}
+		return fname, statusUnexamined, ""
+	}
+
+	status = statusNormal
+	newf = set.form.String(fname)
+	if problem = invalidName(fname); problem != "" {
+		if set.invalidRepl != "" {
+			newf = set.form.String(replaceInvalid(fname, set.invalidRepl))
+		} else if quarantineAbs != "" {
+			return fname, statusQuarantined, problem
+		} else {
+			// the normalizer is not defined on such names
+			if report {
+				warn(name, "%s; not renamed", problem)
+			}
+			newf = fname
+			status = statusInvalid
+		}
+	}
+	if hasControlChars(newf) {
+		if set.controlRepl != "" {
+			newf = replaceControlChars(newf, set.controlRepl)
+		} else {
+			if report {
+				warn(name, "name contains control characters")
+			}
+			status = statusControl
+		}
+	}
+	if set.canonical && newf != fname && changesBeyondCanonical(fname, set.form.String(fname)) {
+		if report {
+			warn(name, "skipped; %q is not canonically equivalent", newf)
+		}
+		return fname, statusSkipped, ""
+	}
+	if newf != fname {
+		if reason := denied(name, entry); reason != "" {
+			if report {
+				warn(name, "skipped; %s", reason)
+				skippedOwner++
+			}
+			return fname, statusSkipped, ""
+		}
+	}
+	return
+}
+
+// process a file. oldName is the path of the file before any renaming in this run,
+// which differs from originalName when a parent directory has been renamed.
+// form is the normalization form for the file, which may be overridden per directory.
+func process(originalName, oldName string, form norm.Form) (err error) {
 	var fInfo os.FileInfo
-	fInfo, err = os.Stat(originalName)
+	fInfo, err = fsys.Lstat(originalName)
 	if err != nil {
 		return
 	}
+	entry := fInfo // the file itself, not a link target
+	isLink := fInfo.Mode()&os.ModeSymlink != 0
+	if isLink {
+		// follow the link; a dangling link is processed as a file
+		if target, e := fsys.Stat(originalName); e == nil {
+			fInfo = target
+		}
+	}
 
+	if isQuarantine(originalName) {
+		return nil
+	}
+	scanCount++
 	dir, fname := filepath.Split(originalName)
 
+	dirForm := form
+	set, skip := settingsFor(originalName, fInfo.IsDir(), form)
+	if skip {
+		recordInventory(oldName, oldName, statusExcluded, fInfo)
+		return nil
+	}
+	form = set.form
+
 	actualName := originalName // the name of actual file based on dryrun flag
-	newf := normalize(fname)
-	newName := filepath.Join(dir, newf)
+	newf, status, problem := normalizedName(originalName, fname, fInfo, entry, set, true)
+	if status == statusQuarantined {
+		quarantineFile(originalName, problem)
+		recordInventory(oldName, oldName, statusQuarantined, fInfo)
+		return nil
+	}
+	examined := status != statusUnexamined // false for files only descended into
 
-	if newf != fname { // name normalized
-		fileCount++
+	// for dry-run; get possibly renamed file path
+	fixedDir := dirFixed[dir]
+	if fixedDir == "" {
+		fixedDir = dir
+	}
+	newName := filepath.Join(fixedDir, newf)
+
+	if examined {
+		checkTarget(newName, newf)
+	}
 
-		// for dry-run; get possibly renamed file path
-		fixedDir := dirFixed[dir]
-		if fixedDir == "" {
-			fixedDir = dir
+	if newf != fname {
+		if reason := collision(originalName, entry, newf); reason != "" {
+			if pruneIdentical && identicalFile(originalName, entry, newf) {
+				if pruneFile(originalName, newf) {
+					recordInventory(oldName, oldName, statusPruned, fInfo)
+				} else {
+					recordInventory(oldName, oldName, statusFailed, fInfo)
+					leftCount++
+				}
+				return nil
+			}
+			if quarantineAbs != "" {
+				quarantineFile(originalName, reason)
+				recordInventory(oldName, oldName, statusQuarantined, fInfo)
+				return nil
+			}
+			addProblem(originalName, newf, reason)
+			newf = fname
+			status = statusCollision
+		} else if deferRename(oldName, newName, form) {
+			newf = fname
+			status = statusDeferred
+		}
+	}
+
+	// rename the file
+	if newf != fname && !dryrun {
+		if e := fsys.Rename(originalName, newName); e != nil {
+			addProblem(originalName, newf, e.Error())
+			newf = fname
+			status = statusFailed
 		}
+	}
+	if newf == fname {
 		newName = filepath.Join(fixedDir, newf)
+	}
+
+	var w io.Writer // where the file is printed
+
+	if newf != fname { // name normalized
+		fileCount++
+		status = statusRenamed
+		var explanation []string
+		if explainChanges {
+			explanation = explain(fname, newf, form)
+		}
 
 		// print the filePath
 		if !quiet {
-			if printBoth {
-				fmt.Printf("%s\n  -> %s\n", originalName, newName)
+			w = resultWriter(newName)
+			if outputTemplate != nil {
+				err = printFormatted(w, displayPath(oldName), displayPath(newName), form)
+				if err != nil {
+					return
+				}
 			} else {
-				fmt.Printf("%s\n", newName)
+				// link to where the file will be after this step
+				target := newName
+				if dryrun {
+					target = originalName
+				}
+				if printBoth {
+					fmt.Fprintf(w, "%s\n  -> %s\n", linkPath(displayPath(originalName), target), linkPath(displayPath(newName), target))
+				} else {
+					fmt.Fprintf(w, "%s\n", linkPath(displayPath(newName), target))
+				}
+				for _, x := range explanation {
+					fmt.Fprintf(w, "  (%s)\n", x)
+				}
 			}
 		}
 
-		// rename the file
 		if !dryrun {
-			err = os.Rename(originalName, newName)
-			if err != nil {
-				return
-			}
 			actualName = newName
+			runExecHook(originalName, newName)
+		}
+		recordRename(originalName, actualName, newf)
+		addReport(oldName, newName, form, fileID(actualName, entry), explanation)
+	}
+
+	recordInventory(oldName, newName, status, fInfo)
+	if status != statusNormal && status != statusRenamed {
+		leftCount++
+	}
+
+	if showLengths && examined {
+		printed := w != nil
+		if !printed {
+			w = resultWriter(newName)
 		}
+		printLengths(w, oldName, newName, printed)
+	}
+
+	if isLink {
+		recordSymlink(actualName)
+	} else if !fInfo.IsDir() {
+		recordVisit(actualName)
 	}
 
 	if fInfo.IsDir() {
@@ -100,17 +406,45 @@ func process(originalName string) (err error) {
 		newName = filepath.Join(newName, "") + sep
 		dirFixed[originalName] = newName
 		if recurse {
-			d, e := os.ReadDir(actualName)
+			form = dirForm // a rule for the directory does not apply to its files
+			if autoForm {
+				form = formForDir(actualName)
+			}
+			var skip bool
+			form, skip, err = readDirConfig(actualName, form)
+			if err != nil {
+				warn(filepath.Join(actualName, rcFileName), "%v; ignored", err)
+			}
+			if skip {
+				return nil
+			}
+
+			// an unchanged directory: only subdirectories are searched
+			if subdirs, ok := unchangedDir(actualName, fInfo, form); ok {
+				for _, sub := range subdirs {
+					err = process(filepath.Join(actualName, sub), filepath.Join(oldName, sub), form)
+					if err != nil && !os.IsNotExist(err) {
+						return
+					}
+				}
+				return nil
+			}
+
+			d, e := fsys.ReadDir(actualName)
 			if e != nil {
-				return e
+				addProblem(actualName, "", e.Error())
+				leftCount++
+				return nil
 			}
+			left := leftCount
 			for _, f := range d {
 				subf := filepath.Join(actualName, f.Name())
-				err = process(subf)
+				err = process(subf, filepath.Join(oldName, f.Name()), form)
 				if err != nil {
 					return
 				}
 			}
+			recordDirState(actualName, form, leftCount == left)
 			return nil
 		}
 	}
@@ -120,52 +454,390 @@ func process(originalName string) (err error) {
 
 func run() (err error) {
 
-	formName = strings.ToUpper(formName)
-	switch formName {
-	case "NFC", "WIN": // Canonical equivalence, Composing
-		formCode = norm.NFC
-	case "NFD", "MAC": // Canonical equivalence, Decomposing
-		formCode = norm.NFD
+	err = loadConfigOnce()
+	if err != nil {
+		return
+	}
 
-	case "NFKC": // Kompatibility equivalence, Composing
-		formCode = norm.NFKC
-	case "NFKD": // Kompatibility equivalence, Decomposing
-		formCode = norm.NFKD
-	default:
-		return fmt.Errorf("invalid normalization form")
+	// a profile sets options before any of them is used
+	var profileRoots []string
+	if profileName != "" {
+		profileRoots, err = applyProfile(profileName)
+		if err != nil {
+			return
+		}
+	}
+
+	setASCIIEscape(asciiEscape)
+
+	form, auto, err := resolveForm(formName)
+	if err != nil {
+		return
+	}
+	formCode, autoForm = form, auto
+	runForm = formString(form)
+	if auto {
+		runForm = "AUTO"
+	}
+
+	// files to process: the arguments in the form given by -form, and each -root in its own form
+	var roots []*root
+	if flag.NArg() > 0 {
+		roots = append(roots, &root{patterns: flag.Args(), form: form, auto: auto})
+	}
+	rootSpecs := []string(rootList)
+	if len(roots) == 0 && len(rootSpecs) == 0 {
+		rootSpecs = profileRoots
+	}
+	for _, spec := range rootSpecs {
+		r := &root{form: form, auto: auto}
+		path, formSpec, e := parseRoot(spec)
+		if e != nil {
+			return e
+		}
+		if formSpec != "" {
+			r.form, r.auto, _ = resolveForm(formSpec)
+		}
+		r.patterns = []string{path}
+		roots = append(roots, r)
+	}
+
+	// only the files a previous run failed on
+	var failed []string
+	if retryFailed != "" {
+		failed, err = failedFiles(retryFailed)
+		if err != nil {
+			return
+		}
+		if len(failed) == 0 {
+			fmt.Fprintf(stdout, "no failed files left to retry in %s\n", retryFailed)
+			return
+		}
+	} else if reportFile != "" {
+		failed = offerRetry(reportFile, roots)
+	}
+	if len(failed) > 0 {
+		roots = []*root{{names: failed, form: form, auto: auto}}
+	}
+
+	if len(roots) == 0 {
+		return fmt.Errorf("no files to process")
+	}
+
+	refTypes, err := parseRefTypes(fixRefTypes)
+	if err != nil {
+		return
+	}
+	err = checkReplacement(controlReplacement)
+	if err != nil {
+		return fmt.Errorf("-replace-control: %w", err)
+	}
+	err = checkInvalidReplacement(invalidReplacement)
+	if err != nil {
+		return fmt.Errorf("-replace-invalid: %w", err)
+	}
+	if execCommand != "" {
+		execWords, err = splitCommand(execCommand)
+		if err != nil {
+			return
+		}
+	}
+	if outputFormat != "" {
+		err = parseFormat(outputFormat)
+		if err != nil {
+			return
+		}
+	}
+	if newerThanSpec != "" {
+		newerThan, err = parseNewerThan(newerThanSpec)
+		if err != nil {
+			return
+		}
+	}
+	err = parseWarnings(warningsOutput)
+	if err != nil {
+		return
+	}
+	err = setPathBase(relativeTo)
+	if err != nil {
+		return
+	}
+	err = parseSort(sortOrder)
+	if err != nil {
+		return
+	}
+	err = setHyperlinks(hyperlinkMode)
+	if err != nil {
+		return
+	}
+	err = parseLimits(limitSpec)
+	if err != nil {
+		return
+	}
+	if targetName != "" {
+		target, err = findTarget(targetName)
+		if err != nil {
+			return
+		}
+		checked = append(checked, target)
+	}
+	if compatTargets != "" {
+		for _, name := range strings.Split(compatTargets, ",") {
+			var t *targetOS
+			t, err = findLegacyTarget(strings.TrimSpace(name))
+			if err != nil {
+				return
+			}
+			checked = append(checked, t)
+		}
+	}
+
+	for _, r := range roots {
+		if r.names == nil {
+			r.names, err = expandArgs(r.patterns)
+			if err != nil {
+				return
+			}
+		}
+		if children {
+			r.names, err = expandChildren(r.names)
+			if err != nil {
+				return
+			}
+		}
+		if recurse {
+			r.names = dropNested(r.names)
+		}
+		if !force {
+			for _, name := range r.names {
+				err = checkProtected(name)
+				if err != nil {
+					return
+				}
+			}
+		}
+	}
+	if stateFileName != "" {
+		err = loadState(stateFileName)
+		if err != nil {
+			return
+		}
+	}
+	if estimateOnly {
+		for _, r := range roots {
+			err = estimate(r.names, r.form)
+			if err != nil {
+				return
+			}
+		}
+		return
+	}
+	if ownedBy != "" {
+		ownerUID, err = lookupUID(ownedBy)
+		if err != nil {
+			return
+		}
+	}
+	if quarantineDir != "" {
+		err = openQuarantine(quarantineDir)
+		if err != nil {
+			return
+		}
+	}
+	if inventoryName != "" {
+		err = openInventory(inventoryName)
+		if err != nil {
+			return
+		}
+		defer func() {
+			if e := closeInventory(); err == nil {
+				err = e
+			}
+		}()
+	}
+	if skipOpen {
+		openSet, err = openFiles()
+		if err != nil {
+			return
+		}
+	}
+	var prevReport *report
+	if compareFile != "" {
+		prevReport, err = readReport(compareFile)
+		if err != nil {
+			return
+		}
+		quiet = true // only the differences are printed
+	}
+	if !dryrun && !assumeYes {
+		for _, r := range roots {
+			if (isCompatForm(r.form) && !canonicalOnly) || compatRules() {
+				err = confirmCompat(r.names, r.form)
+				if err != nil {
+					return
+				}
+			}
+		}
+	}
+	if maxRenames > 0 && !dryrun {
+		total := 0
+		for _, r := range roots {
+			n, e := countChanges(r.names, r.form, r.auto)
+			if e != nil {
+				return e
+			}
+			total += n
+		}
+		if total > maxRenames {
+			return fmt.Errorf("%d file(s) to rename exceed -max-renames %d; nothing renamed", total, maxRenames)
+		}
+	}
+	for _, r := range roots {
+		autoForm = r.auto // read when descending into directories
+		for _, name := range r.names {
+			form := r.form
+			if r.auto {
+				form = formForDir(filepath.Dir(name))
+			}
+			err = process(name, name, form)
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	printResults()
+
+	if stateFileName != "" && !dryrun {
+		err = saveState(stateFileName)
+		if err != nil {
+			return
+		}
+	}
+
+	// update references to renamed files
+	err = fixRefs(refTypes)
+	if err != nil {
+		return
+	}
+	if fixSymlinks {
+		err = fixLinkTargets()
+		if err != nil {
+			return
+		}
 	}
 
-	args := flag.Args()
-	for _, pattern := range args {
-		var l []string
-		l, err = filepath.Glob(pattern)
+	if chunkSize > 0 {
+		err = writePlan(planFile)
 		if err != nil {
 			return
 		}
-		if l == nil {
-			continue
+	}
+	if reportFile != "" {
+		err = writeReport(reportFile)
+		if err != nil {
+			return
 		}
+	}
+	if prevReport != nil {
+		printReportDiff(prevReport)
+	}
+	if skippedOpen > 0 {
+		notice("%d file(s) open by other processes were skipped; run again later to rename them", skippedOpen)
+	}
+	if len(pruned) > 0 {
+		notice("%d duplicate file(s) identical to the files with their normalized names were pruned", len(pruned))
+	}
+	if quarantined > 0 {
+		notice("%d file(s) that cannot be normalized were moved to %s", quarantined, quarantineAbs)
+	}
+	if skippedOwner > 0 {
+		notice("%d file(s) not owned by or not renamable by the user were skipped", skippedOwner)
+	}
+
+	printTargetSummary()
+	printProblems()
+	if len(problems) > 0 {
+		return fmt.Errorf("%d file(s) could not be renamed", len(problems))
+	}
 
-		for _, name := range l {
+	return
+}
 
-			err = process(name)
+// expand braces and glob patterns in command line arguments.
+// A file matched by several patterns is listed once.
+func expandArgs(patterns []string) (names []string, err error) {
+	seen := make(map[string]bool)
+	for _, pattern := range patterns {
+		for _, p := range expandBraces(pattern) {
+			if ignoreCase {
+				p = caseFoldPattern(p)
+			}
+			var l []string
+			l, err = fsys.Glob(p)
 			if err != nil {
 				return
 			}
+			for _, name := range l {
+				key := filepath.Clean(name)
+				if abs, e := filepath.Abs(name); e == nil {
+					key = abs
+				}
+				if !seen[key] {
+					seen[key] = true
+					names = append(names, name)
+				}
+			}
 		}
 	}
+	return
+}
 
+// call fn for a file, and for all files in it if it is a directory and recursive is set
+func walk(name string, recursive bool, fn func(name string, fInfo os.FileInfo) error) (err error) {
+	fInfo, err := fsys.Stat(name)
+	if err != nil {
+		return
+	}
+	err = fn(name, fInfo)
+	if err != nil || !recursive || !fInfo.IsDir() {
+		return
+	}
+	d, err := fsys.ReadDir(name)
+	if err != nil {
+		return
+	}
+	for _, f := range d {
+		err = walk(filepath.Join(name, f.Name()), recursive, fn)
+		if err != nil {
+			return
+		}
+	}
 	return
 }
 
 func main() {
 	var err error
 
-	flag.StringVar(&formName, "form", formName, "Unicode normalization type. One of NFC, NFD, NFKC, NFKD,\nor WIN, MAC")
+	// subcommands; a file of the same name, as from 'nufn *', is a file to rename
+	if len(os.Args) > 1 {
+		if c := findCommand(os.Args[1]); c != nil && !fileExists(os.Args[1]) {
+			err = runCommand(c, os.Args[2:])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	flag.StringVar(&formName, "form", formName, "Unicode normalization type. One of NFC, NFD, NFKC, NFKD,\nor WIN, MAC, or AUTO (NFD on macOS file systems, NFC on others)")
 	flag.StringVar(&formName, "f", formName, "shorthand for '-form'")
 
 	flag.BoolVar(&recurse, "r", recurse, "recurse subdirectories")
 
+	flag.Var(&rootList, "root", "process a `path[:FORM]` in its own normalization type, or in -form if omitted;\nmay be repeated, e.g. '-root /mac-share:NFD -root /win-share:NFC'")
+
 	flag.BoolVar(&quiet, "q", quiet, "quiet; do not print filenames")
 
 	flag.BoolVar(&dryrun, "d", dryrun, "shorthand for '-dryrun'")
@@ -174,6 +846,87 @@ func main() {
 	flag.BoolVar(&printBoth, "both", printBoth, "print both original and changed filename")
 	flag.BoolVar(&printBoth, "b", printBoth, "shorthand for '-both'")
 
+	flag.StringVar(&fixRefTypes, "fix-refs", fixRefTypes, "after renaming, update paths to renamed files in processed files of these\ncomma-separated types: "+refTypeList())
+	flag.BoolVar(&fixSymlinks, "fix-symlinks", fixSymlinks, "after renaming, update processed symbolic links pointing to renamed files")
+
+	flag.StringVar(&reportFile, "report", reportFile, "write a JSON report of renamed files to this file")
+
+	flag.StringVar(&targetName, "target", targetName, "check normalized names against other naming constraints of\nthe target OS: windows, linux or macos")
+
+	flag.StringVar(&compatTargets, "compat", compatTargets, "check normalized names against legacy software and media, a comma separated `list`\nof smb1 (UCS-2 clients), fat32 (VFAT long names) and joliet (CD-ROM)")
+
+	flag.BoolVar(&showLengths, "lengths", showLengths, "print path lengths of every file before and after normalization")
+	flag.StringVar(&limitSpec, "limits", limitSpec, "length limits for '-lengths', as 'name=255,path=4096,win=260'\n(name and path in bytes, win in UTF-16 characters)")
+
+	flag.StringVar(&execCommand, "exec", execCommand, "run a command after each rename; {old} and {new} are replaced with the paths,\ne.g. 'reindex --move {old} {new}'")
+
+	flag.StringVar(&onComplete, "on-complete", onComplete, "run a command once at the end, with a JSON summary of the run as its input")
+
+	flag.BoolVar(&assumeYes, "yes", assumeYes, "do not ask for confirmation before irreversible NFKC or NFKD renames")
+
+	flag.StringVar(&controlReplacement, "replace-control", controlReplacement, "replace control characters (newline, tab, escape, ...) in names with\nthis string; names with control characters are reported otherwise")
+
+	flag.StringVar(&invalidReplacement, "replace-invalid", invalidReplacement, "replace invalid UTF-8, unpaired surrogates and noncharacters in names\nwith this string; such names are reported and left alone otherwise")
+
+	flag.BoolVar(&skipOpen, "skip-open", skipOpen, "skip and list files currently open by other processes")
+
+	flag.IntVar(&chunkSize, "chunk", chunkSize, "rename at most `N` files in a run; run again to continue with the rest")
+
+	flag.IntVar(&maxRenames, "max-renames", maxRenames, "abort before renaming anything if more than `N` files would be renamed")
+
+	flag.StringVar(&planFile, "plan", planFile, "with '-chunk', write the renames left for later runs to this `file`, in the report format")
+
+	flag.StringVar(&inventoryName, "inventory", inventoryName, "record every scanned file with its status, size and modification time\nin this `file`: CSV for .csv files, or JSON lines")
+
+	flag.StringVar(&retryFailed, "retry-failed", retryFailed, "process only the files a previous run could not rename, from its `report`")
+
+	flag.BoolVar(&pruneIdentical, "prune-identical", pruneIdentical, "remove a file whose normalized name is taken by a file with the same contents,\ne.g. sync conflict copies; with '-quarantine', move it there instead")
+
+	flag.StringVar(&quarantineDir, "quarantine", quarantineDir, "move files that cannot be normalized, for invalid names or collisions,\nto this `directory` on the same volume, and log them there")
+
+	flag.StringVar(&ownedBy, "owned-by", ownedBy, "rename only files owned by this `user`; others are listed and skipped")
+
+	flag.BoolVar(&writableOnly, "writable-only", writableOnly, "rename only files the user has permission to rename; others are listed and skipped")
+
+	flag.BoolVar(&canonicalOnly, "max-visual-change", canonicalOnly, "skip and report renames that are not canonically equivalent\nto the original name (possible only with NFKC or NFKD)")
+
+	flag.StringVar(&newerThanSpec, "newer-than", newerThanSpec, "examine only files modified after this time ('2006-01-02 15:04:05')\nor after the modification time of this file; directories are still searched")
+
+	flag.StringVar(&stateFileName, "state", stateFileName, "keep scan state in this file, to skip unchanged directories in later\nrecursive runs")
+
+	flag.BoolVar(&estimateOnly, "estimate", estimateOnly, "print only the numbers of names needing normalization per directory;\nnothing is renamed")
+
+	flag.StringVar(&profileName, "profile", profileName, "use the settings and files of a named profile in the configuration file")
+
+	flag.BoolVar(&children, "children", children, "for directory arguments, process the entries inside instead of the directory itself")
+
+	flag.BoolVar(&ignoreCase, "iglob", ignoreCase, "match the file names of patterns case-insensitively, e.g. '*.jpg' also matches\n'*.JPG'; directory names are matched as given")
+
+	flag.BoolVar(&force, "force", force, "process protected system locations and home directories")
+	flag.StringVar(&configFile, "config", configFile, "configuration file\n(default: normalize-unicode-filename/config in the user configuration directory)")
+
+	flag.StringVar(&hyperlinkMode, "hyperlinks", hyperlinkMode, "print paths as clickable terminal hyperlinks: auto, always or never")
+
+	flag.StringVar(&sortOrder, "sort", sortOrder, "print renamed files at the end of the run, sorted in `order`: normalized, collating\nnew names for the locale (LANG), or bytewise; none prints them as processed")
+
+	flag.BoolVar(&asciiEscape, "ascii-escape", asciiEscape, "escape non-ASCII characters in printed names and warnings as \\uXXXX (UTF-16, as in JSON),\nfor logs that must be ASCII")
+
+	flag.StringVar(&warningsOutput, "warnings", warningsOutput, "write warnings and other diagnostics to stderr as `text`, json (one object per line), or off")
+
+	flag.BoolVar(&absPaths, "abs", absPaths, "print absolute paths")
+
+	flag.StringVar(&relativeTo, "relative-to", relativeTo, "print paths, and write them in reports, relative to this `directory`")
+
+	flag.BoolVar(&explainChanges, "explain", explainChanges, "explain how each name changed, e.g. which characters were composed at which offset,\nunder the printed name and in the report")
+
+	flag.StringVar(&outputFormat, "format", outputFormat, "print renamed files with a Go template; fields are .Old, .New and .Form,\ne.g. '{{.Old}} -> {{.New}} ({{.Form}})'")
+
+	flag.StringVar(&compareFile, "compare-report", compareFile, "print only files that are new or resolved since a previous report,\ne.g. of last week's dry run")
+
+	flag.BoolVar(&showSchema, "schema", showSchema, "print the JSON Schema of the report written with '-report', then exit")
+
+	flag.BoolVar(&showVersion, "version", showVersion, "print version and build information, then exit")
+
 	flag.Usage = func() {
 		o := flag.CommandLine.Output()
 		execName := os.Args[0]
@@ -183,16 +936,30 @@ func main() {
 		fmt.Fprintf(o, "Usage: %s [option] filename [filename...]\n\n", execName)
 		flag.PrintDefaults()
 		fmt.Fprintln(o)
+		if loadConfigOnce() == nil && len(conf.aliases) > 0 {
+			fmt.Fprintf(o, "Form aliases in the configuration:\n  %s\n\n", strings.Join(aliasList(), ", "))
+		}
+		printCommands()
 		fmt.Fprintf(o, "Examples:\n")
 		fmt.Fprintf(o, help_examples, execName)
 		fmt.Fprintf(o, "Memo:\n")
-		fmt.Fprintf(o, "Please note that NFKC and NFKD may cause irreversible changes. Be careful using them.")
+		fmt.Fprintf(o, "Please note that NFKC and NFKD may cause irreversible changes. Be careful using them.\n")
+		fmt.Fprintf(o, "Such changes are listed for confirmation before renaming, unless -yes is given.")
 		fmt.Fprintln(o)
 	}
 
 	flag.Parse()
 
-	if flag.NArg() == 0 {
+	if showVersion {
+		printVersion(os.Stdout, os.Args[0])
+		os.Exit(0)
+	}
+	if showSchema {
+		fmt.Print(reportSchema)
+		os.Exit(0)
+	}
+
+	if flag.NArg() == 0 && profileName == "" && len(rootList) == 0 && retryFailed == "" {
 		flag.Usage()
 		os.Exit(0)
 	}
@@ -200,8 +967,14 @@ func main() {
 	// run main
 	err = run()
 
+	if onComplete != "" {
+		if e := runCompleteHook(onComplete, err); e != nil {
+			fmt.Fprintln(os.Stderr, "on-complete:", e.Error())
+		}
+	}
+
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err.Error())
+		fmt.Fprintln(stderr, err.Error())
 		os.Exit(1)
 	}
 }
@@ -218,4 +991,5 @@ func init() {
 	default:
 		formName = "NFC"
 	}
+	defaultFormName = formName
 }
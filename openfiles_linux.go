@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// paths of files open by any process, from /proc/*/fd.
+// Without privileges, only the files of the user's own processes are visible.
+func openFiles() (files map[string]bool, err error) {
+	fds, err := filepath.Glob("/proc/[0-9]*/fd/*")
+	if err != nil {
+		return
+	}
+	files = make(map[string]bool)
+	self := "/proc/" + strconv.Itoa(os.Getpid()) + "/"
+	for _, fd := range fds {
+		if strings.HasPrefix(fd, self) {
+			continue
+		}
+		if target, e := os.Readlink(fd); e == nil && filepath.IsAbs(target) {
+			files[target] = true
+		}
+	}
+	return
+}
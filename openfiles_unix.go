@@ -0,0 +1,26 @@
+//go:build !linux && !windows
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+)
+
+// paths of files open by any process, from lsof
+func openFiles() (files map[string]bool, err error) {
+	out, err := exec.Command("lsof", "-n", "-F", "n").Output()
+	if err != nil && len(out) == 0 {
+		return nil, fmt.Errorf("lsof: %w", err)
+	}
+	files = make(map[string]bool)
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		if l := sc.Text(); len(l) > 1 && l[0] == 'n' && l[1] == '/' {
+			files[l[1:]] = true
+		}
+	}
+	return files, nil
+}
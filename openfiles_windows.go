@@ -0,0 +1,9 @@
+package main
+
+import "fmt"
+
+// paths of files open by any process; not supported on Windows, where
+// renaming a file opened by another process fails with a sharing violation instead
+func openFiles() (map[string]bool, error) {
+	return nil, fmt.Errorf("-skip-open is not supported on Windows")
+}
@@ -0,0 +1,24 @@
+//go:build !unix
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// the user ID of a user name; file owners are not checked on this OS
+func lookupUID(name string) (int, error) {
+	return -1, fmt.Errorf("-owned-by is not supported on this OS")
+}
+
+// the owner of a file; not available on this OS
+func fileOwner(fInfo os.FileInfo) (uid int, ok bool) {
+	return -1, false
+}
+
+// true if the invoking user may rename a file; not checked on this OS,
+// where a denied rename is reported as an error instead
+func canRename(name string, fInfo os.FileInfo) bool {
+	return true
+}
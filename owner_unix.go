@@ -0,0 +1,54 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// the user ID of a user name or a numeric ID
+func lookupUID(name string) (uid int, err error) {
+	if uid, err = strconv.Atoi(name); err == nil {
+		return
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		return
+	}
+	return strconv.Atoi(u.Uid)
+}
+
+// the owner of a file
+func fileOwner(fInfo os.FileInfo) (uid int, ok bool) {
+	st, ok := fInfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return -1, false
+	}
+	return int(st.Uid), true
+}
+
+// true if the invoking user may rename a file: the directory must be writable,
+// and in a sticky directory like /tmp the file or the directory must be owned by the user
+func canRename(name string, fInfo os.FileInfo) bool {
+	dir := filepath.Dir(name)
+	if syscall.Access(dir, 0x2|0x1) != nil { // W_OK|X_OK
+		return false
+	}
+	dInfo, err := os.Stat(dir)
+	if err != nil {
+		return false
+	}
+	euid := os.Geteuid()
+	if dInfo.Mode()&os.ModeSticky == 0 || euid == 0 {
+		return true
+	}
+	if uid, ok := fileOwner(fInfo); ok && uid == euid {
+		return true
+	}
+	uid, ok := fileOwner(dInfo)
+	return ok && uid == euid
+}
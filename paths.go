@@ -0,0 +1,44 @@
+package main
+
+import "path/filepath"
+
+// absolute base directory of printed and reported paths, with -relative-to
+var pathBase string
+
+func setPathBase(dir string) (err error) {
+	if dir == "" {
+		return
+	}
+	pathBase, err = filepath.Abs(dir)
+	return
+}
+
+// a path as printed: relative to the base with -relative-to, absolute with -abs,
+// or as given otherwise
+func displayPath(name string) string {
+	if pathBase == "" && !absPaths {
+		return name
+	}
+	p, err := reportPath(name)
+	if err != nil {
+		return name
+	}
+	return p
+}
+
+// a path as recorded in reports: relative to the base with -relative-to, or absolute
+func reportPath(name string) (p string, err error) {
+	p, err = filepath.Abs(name)
+	if err != nil || pathBase == "" {
+		return
+	}
+	return filepath.Rel(pathBase, p)
+}
+
+// a path of a report made with -relative-to, resolved against its base
+func resolveReportPath(r *report, name string) string {
+	if r.Base == "" || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(r.Base, name)
+}
@@ -0,0 +1,31 @@
+package main
+
+import "golang.org/x/text/unicode/norm"
+
+// renames left for later runs with -chunk
+var pending []reportEntry
+
+// true if a rename exceeds the chunk size and is left for a later run
+func deferRename(oldName, newName string, form norm.Form) bool {
+	if chunkSize <= 0 || fileCount < chunkSize {
+		return false
+	}
+	if e, ok := newReportEntry(oldName, newName, form); ok {
+		pending = append(pending, e)
+	}
+	return true
+}
+
+// write the pending renames as a plan, and tell how to continue
+func writePlan(filename string) (err error) {
+	if filename != "" {
+		err = saveReport(filename, report{DryRun: true, Entries: pending})
+		if err != nil {
+			return
+		}
+	}
+	if len(pending) > 0 {
+		notice("%d rename(s) left after the first %d; run again to continue", len(pending), chunkSize)
+	}
+	return
+}
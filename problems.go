@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// a file that could not be renamed
+type problem struct {
+	Path   string `json:"path"` // path of the file, as in reports
+	New    string `json:"new"`  // the name it would be renamed to
+	Reason string `json:"reason"`
+}
+
+var (
+	problems []problem
+	planned  = make(map[string]string) // new names taken in this run, by planKey
+)
+
+// number of examples printed per directory
+const problemExamples = 3
+
+// record a file that could not be renamed; the run continues with other files
+func addProblem(name, newf, reason string) {
+	p, err := reportPath(name)
+	if err != nil {
+		p = name
+	}
+	problems = append(problems, problem{Path: p, New: newf, Reason: reason})
+}
+
+// the reason a file cannot be renamed to a new name in its directory without replacing another file, or ""
+func collision(name string, entry os.FileInfo, newf string) string {
+	dir := filepath.Dir(name)
+	insensitive := isCaseInsensitive(name, entry)
+	key := planKey(dir, newf, insensitive)
+	if other, ok := planned[key]; ok {
+		if other != newf {
+			return fmt.Sprintf("another file is renamed to %q, the same name on this case-insensitive volume", other)
+		}
+		return fmt.Sprintf("another file is renamed to %q", newf)
+	}
+	if tInfo, err := fsys.Lstat(filepath.Join(dir, newf)); err == nil && !fsys.SameFile(entry, tInfo) {
+		// on normalization- or case-insensitive file systems the new name may find the file itself
+		return fmt.Sprintf("%q already exists", newf)
+	}
+	planned[key] = newf
+	return ""
+}
+
+// print problems grouped by directory, with a few examples each
+func printProblems() {
+	if len(problems) == 0 || warningsMode == "off" {
+		return
+	}
+	if warningsMode == "json" {
+		for _, p := range problems {
+			diag("error", p.Path, p.Reason)
+		}
+		return
+	}
+	byDir := make(map[string][]problem)
+	var dirs []string
+	for _, p := range problems {
+		d := filepath.Dir(p.Path)
+		if byDir[d] == nil {
+			dirs = append(dirs, d)
+		}
+		byDir[d] = append(byDir[d], p)
+	}
+	sort.Strings(dirs)
+	fmt.Fprintf(stderr, "%d file(s) not renamed:\n", len(problems))
+	for _, d := range dirs {
+		l := byDir[d]
+		fmt.Fprintf(stderr, "%s: %d\n", d, len(l))
+		for i, p := range l {
+			if i == problemExamples {
+				fmt.Fprintf(stderr, "  ... and %d more\n", len(l)-i)
+				break
+			}
+			fmt.Fprintf(stderr, "  %s: %s\n", filepath.Base(p.Path), p.Reason)
+		}
+	}
+}
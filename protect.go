@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// system locations never processed without -force: tree lists locations protected with everything
+// in them, and exact those protected only themselves, as the parents of user files
+func defaultProtected() (tree, exact []string) {
+	switch runtime.GOOS {
+	case "windows":
+		sys := os.Getenv("SystemDrive")
+		if sys == "" {
+			sys = "C:"
+		}
+		tree = []string{
+			sys + `\Windows`,
+			sys + `\Program Files`,
+			sys + `\Program Files (x86)`,
+			sys + `\ProgramData`,
+		}
+		if root := os.Getenv("SystemRoot"); root != "" {
+			tree = append(tree, root)
+		}
+		exact = []string{sys + `\`, sys + `\Users`}
+	default:
+		tree = []string{
+			"/bin", "/boot", "/dev", "/etc", "/lib", "/lib32", "/lib64",
+			"/opt", "/proc", "/run", "/sbin", "/sys", "/usr", "/var",
+		}
+		exact = []string{"/", "/home", "/root", "/srv"}
+		if runtime.GOOS == "darwin" {
+			tree = append(tree, "/Applications", "/Library", "/System")
+			exact = append(exact, "/Users", "/Volumes", "/private")
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		exact = append(exact, home)
+	}
+	return
+}
+
+// compare paths in the way of the OS
+func samePath(a, b string) bool {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// true if a path is inside a directory, compared in the way of the OS
+func insidePath(path, dir string) bool {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return isInside(strings.ToLower(path), strings.ToLower(dir))
+	}
+	return isInside(path, dir)
+}
+
+// return an error if a file is a protected location, or inside one protected with its subtree
+func checkProtected(name string) error {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return err
+	}
+	candidates := []string{abs}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil && resolved != abs {
+		candidates = append(candidates, resolved)
+	}
+
+	tree, exact := defaultProtected()
+	tree = append(tree, conf.protect...)
+	for _, c := range candidates {
+		for _, p := range tree {
+			p = cleanProtected(p)
+			if samePath(c, p) || insidePath(c, p) {
+				return fmt.Errorf("refusing to process protected location '%s'; use -force to override", name)
+			}
+		}
+		for _, p := range exact {
+			if samePath(c, cleanProtected(p)) {
+				return fmt.Errorf("refusing to process protected location '%s'; use -force to override", name)
+			}
+		}
+	}
+	return nil
+}
+
+// a protected location as a clean path; a bare volume name is its root directory
+func cleanProtected(p string) string {
+	p = filepath.Clean(p)
+	if volume := filepath.VolumeName(p); volume == p {
+		p += string(filepath.Separator)
+	}
+	return p
+}
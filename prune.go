@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+var pruned []reportEntry // removed duplicates, with the files kept
+
+// the SHA-256 hash of the contents of a file
+func hashFile(name string) (sum []byte, err error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return
+	}
+	return h.Sum(nil), nil
+}
+
+// true if a file and the existing file with its normalized name are regular files with the same contents
+func identicalFile(name string, entry os.FileInfo, newf string) bool {
+	if !entry.Mode().IsRegular() {
+		return false
+	}
+	other := filepath.Join(filepath.Dir(name), newf)
+	oInfo, err := fsys.Lstat(other)
+	if err != nil || !oInfo.Mode().IsRegular() || oInfo.Size() != entry.Size() || fsys.SameFile(entry, oInfo) {
+		return false
+	}
+	h1, err := hashFile(name)
+	if err != nil {
+		return false
+	}
+	h2, err := hashFile(other)
+	return err == nil && bytes.Equal(h1, h2)
+}
+
+// remove a duplicate of the file with its normalized name, or move it to the quarantine directory.
+// ok is false if the file could not be removed or moved.
+func pruneFile(name, newf string) (ok bool) {
+	kept := filepath.Join(filepath.Dir(name), newf)
+	if quarantineAbs != "" {
+		if !quarantineFile(name, fmt.Sprintf("identical to %q", newf)) {
+			return false
+		}
+	} else {
+		if !dryrun {
+			if err := fsys.Remove(name); err != nil {
+				addProblem(name, newf, err.Error())
+				return false
+			}
+		}
+		if !quiet {
+			fmt.Fprintf(resultWriter(name), "%s\n  (removed; identical to %s)\n", displayPath(name), displayPath(kept))
+		}
+	}
+	o, e1 := reportPath(name)
+	k, e2 := reportPath(kept)
+	if e1 == nil && e2 == nil {
+		pruned = append(pruned, reportEntry{Old: o, New: k})
+	}
+	return true
+}
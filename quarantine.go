@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// name of the log of quarantined files, in the quarantine directory
+const quarantineLogName = "quarantine.log"
+
+var (
+	quarantineAbs = "" // absolute path of the quarantine directory
+	quarantined   = 0
+)
+
+// create the quarantine directory
+func openQuarantine(dir string) (err error) {
+	quarantineAbs, err = filepath.Abs(dir)
+	if err != nil || dryrun {
+		return
+	}
+	return fsys.MkdirAll(quarantineAbs, 0755)
+}
+
+// true if a file is the quarantine directory, which is not processed
+func isQuarantine(name string) bool {
+	if quarantineAbs == "" {
+		return false
+	}
+	abs, err := filepath.Abs(name)
+	return err == nil && samePath(abs, quarantineAbs)
+}
+
+// a path in the quarantine directory not taken yet
+func quarantinePath(base string) string {
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	dst := filepath.Join(quarantineAbs, base)
+	for n := 1; ; n++ {
+		if _, err := fsys.Lstat(dst); err != nil {
+			return dst
+		}
+		dst = filepath.Join(quarantineAbs, fmt.Sprintf("%s (%d)%s", stem, n, ext))
+	}
+}
+
+// move a file that cannot be normalized into the quarantine directory, and log it.
+// ok is false if the file could not be moved.
+func quarantineFile(name, reason string) (ok bool) {
+	dst := quarantinePath(filepath.Base(name))
+	if !dryrun {
+		if err := fsys.Rename(name, dst); err != nil {
+			addProblem(name, "", fmt.Sprintf("%s; not quarantined: %v", reason, err))
+			return false
+		}
+		if err := logQuarantine(name, dst, reason); err != nil {
+			warn(filepath.Join(quarantineAbs, quarantineLogName), "%v", err)
+		}
+	}
+	quarantined++
+	if !quiet {
+		fmt.Fprintf(resultWriter(name), "%q\n  (quarantined as %q: %s)\n", displayPath(name), displayPath(dst), reason)
+	}
+	return true
+}
+
+// append a line to the quarantine log: time, original path, quarantined path and reason, separated by tabs
+func logQuarantine(name, dst, reason string) (err error) {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return
+	}
+	f, err := fsys.OpenFile(filepath.Join(quarantineAbs, quarantineLogName), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return
+	}
+	_, err = fmt.Fprintf(f, "%s\t%s\t%s\t%s\n", time.Now().Format(time.RFC3339), strconv.Quote(abs), strconv.Quote(dst), reason)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	return
+}
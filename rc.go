@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// per-directory configuration file, applied to the directory's subtree during recursion
+const rcFileName = ".nufnrc"
+
+// read the per-directory configuration of a directory, if any.
+// 'form' overrides the normalization form; 'skip = true' excludes the subtree.
+func readDirConfig(dir string, form norm.Form) (newForm norm.Form, skip bool, err error) {
+	newForm = form
+	filename := filepath.Join(dir, rcFileName)
+	lines, err := readConfigLines(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+	for _, l := range lines {
+		switch {
+		case l.section == "" && l.key == "form":
+			newForm, err = parseForm(l.value)
+			// compatibility forms are confirmed only for the forms of the roots
+			if err == nil && isCompatForm(newForm) && !dryrun && !assumeYes && !canonicalOnly {
+				err = fmt.Errorf("form %s requires -yes or -max-visual-change", formString(newForm))
+			}
+		case l.section == "" && l.key == "skip":
+			skip, err = parseBool(l.value)
+		default:
+			err = fmt.Errorf("unknown setting '%s'", l.key)
+		}
+		if err != nil {
+			return form, false, fmt.Errorf("line %d: %w", l.lineNo, err)
+		}
+	}
+	return
+}
+
+// parse a boolean value, also accepting yes/no and on/off
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "yes", "on":
+		return true, nil
+	case "no", "off":
+		return false, nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("invalid boolean value '%s'", s)
+	}
+	return b, nil
+}
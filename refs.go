@@ -0,0 +1,457 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// a file renamed (or to be renamed in dry-run) during the run
+type renameRecord struct {
+	actual  string // absolute path of the file after the run
+	newBase string // normalized base name
+}
+
+var (
+	// renamed files, by absolute path at the time they were processed
+	renamed = make(map[string]renameRecord)
+
+	// processed non-directory files, after the run
+	visited []string
+
+	// processed symbolic links, after the run
+	symlinks []string
+)
+
+// remember a renamed file for fixing references later
+func recordRename(originalName, actualName, newBase string) {
+	o, e1 := filepath.Abs(originalName)
+	a, e2 := filepath.Abs(actualName)
+	if e1 != nil || e2 != nil {
+		return
+	}
+	renamed[o] = renameRecord{actual: a, newBase: newBase}
+}
+
+// remember a processed file
+func recordVisit(actualName string) {
+	visited = append(visited, actualName)
+}
+
+// remember a processed symbolic link
+func recordSymlink(actualName string) {
+	symlinks = append(symlinks, actualName)
+}
+
+func isPathSep(c byte) bool {
+	return c == '/' || os.IsPathSeparator(c)
+}
+
+// rewrite a file path reference, relative to directory dir, that points to renamed files.
+// Separators and the relative/absolute form of the reference are kept.
+func rewriteRef(dir, ref string) (newRef string, changed bool) {
+	if ref == "" {
+		return ref, false
+	}
+
+	disk := dir // the path of the current component on the disk
+	i := 0
+	if vol := filepath.VolumeName(ref); vol != "" || isPathSep(ref[0]) {
+		i = len(vol)
+		for i < len(ref) && isPathSep(ref[i]) {
+			i++
+		}
+		disk = filepath.Clean(ref[:i])
+	}
+
+	var b strings.Builder
+	b.WriteString(ref[:i])
+	for i < len(ref) {
+		j := i
+		for j < len(ref) && !isPathSep(ref[j]) {
+			j++
+		}
+		comp := ref[i:j]
+		key := filepath.Join(disk, comp)
+		if r, ok := renamed[key]; ok && comp != "." && comp != ".." {
+			comp = r.newBase
+			disk = r.actual
+			changed = true
+		} else {
+			disk = key
+		}
+		b.WriteString(comp)
+
+		// copy separators
+		i = j
+		for i < len(ref) && isPathSep(ref[i]) {
+			b.WriteByte(ref[i])
+			i++
+		}
+	}
+	return b.String(), changed
+}
+
+// a reference fixer rewrites references in the text of a file in directory dir,
+// and returns the number of references changed
+type refFixer func(dir, text string) (string, int)
+
+// reference fixers by file type (extension)
+var refFixers = map[string]refFixer{
+	"m3u":  fixM3U,
+	"m3u8": fixM3U,
+	"cue":  fixCue,
+	"md":   fixMarkdown,
+	"html": fixHTML,
+	"htm":  fixHTML,
+
+	"desktop": fixDesktop,
+	"webloc":  fixWebloc,
+
+	"xmp": fixXMP,
+}
+
+// supported reference file types, sorted
+func refTypeList() string {
+	l := make([]string, 0, len(refFixers))
+	for t := range refFixers {
+		l = append(l, t)
+	}
+	sort.Strings(l)
+	return strings.Join(l, ", ")
+}
+
+// parse a comma-separated list of reference file types
+func parseRefTypes(s string) (types map[string]bool, err error) {
+	types = make(map[string]bool)
+	for _, t := range strings.Split(s, ",") {
+		t = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(t), "."))
+		if t == "" {
+			continue
+		}
+		if refFixers[t] == nil {
+			return nil, fmt.Errorf("unsupported reference file type '%s'; one of %s", t, refTypeList())
+		}
+		types[t] = true
+	}
+	return
+}
+
+// rewrite lines of a text with fn, keeping line endings
+func mapLines(text string, fn func(line string) string) string {
+	lines := strings.SplitAfter(text, "\n")
+	for i, l := range lines {
+		body := strings.TrimRight(l, "\r\n")
+		lines[i] = fn(body) + l[len(body):]
+	}
+	return strings.Join(lines, "")
+}
+
+// m3u/m3u8 playlists: every non-comment line is a path or an URL
+func fixM3U(dir, text string) (string, int) {
+	n := 0
+	text = mapLines(text, func(line string) string {
+		bom := ""
+		if strings.HasPrefix(line, "\ufeff") { // byte order mark
+			bom, line = "\ufeff", line[len("\ufeff"):]
+		}
+		ref := strings.TrimSpace(line)
+		if ref == "" || strings.HasPrefix(ref, "#") || strings.Contains(ref, "://") {
+			return bom + line
+		}
+		newRef, changed := rewriteRef(dir, ref)
+		if changed {
+			n++
+			line = strings.Replace(line, ref, newRef, 1)
+		}
+		return bom + line
+	})
+	return text, n
+}
+
+// FILE "name" TYPE, or FILE name TYPE
+var cueFileLine = regexp.MustCompile(`^(\s*FILE\s+)(?:"([^"]*)"|(\S+))(.*)$`)
+
+// cue sheets: FILE commands refer to media files
+func fixCue(dir, text string) (string, int) {
+	n := 0
+	text = mapLines(text, func(line string) string {
+		m := cueFileLine.FindStringSubmatch(line)
+		if m == nil {
+			return line
+		}
+		quoted, ref := true, m[2]
+		if m[3] != "" {
+			quoted, ref = false, m[3]
+		}
+		newRef, changed := rewriteRef(dir, ref)
+		if !changed {
+			return line
+		}
+		n++
+		if quoted {
+			newRef = `"` + newRef + `"`
+		}
+		return m[1] + newRef + m[4]
+	})
+	return text, n
+}
+
+// replace the first non-empty capture group of every match of re
+func replaceGroup(re *regexp.Regexp, text string, fn func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringSubmatchIndex(text, -1) {
+		for g := 2; g < len(m); g += 2 {
+			if m[g] < 0 || m[g] == m[g+1] {
+				continue
+			}
+			b.WriteString(text[last:m[g]])
+			b.WriteString(fn(text[m[g]:m[g+1]]))
+			last = m[g+1]
+			break
+		}
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// rewrite a relative URL reference; URLs with a scheme or a host, site-absolute paths
+// and fragment-only links are left alone
+func rewriteLink(dir, link string) (string, bool) {
+	if u, err := url.Parse(link); err != nil || u.Scheme != "" || u.Host != "" {
+		return link, false
+	}
+	p, suffix := link, ""
+	if i := strings.IndexAny(link, "?#"); i >= 0 {
+		p, suffix = link[:i], link[i:]
+	}
+	if p == "" || strings.HasPrefix(p, "/") {
+		return link, false
+	}
+	decoded, err := url.PathUnescape(p)
+	if err != nil {
+		return link, false
+	}
+	newPath, changed := rewriteRef(dir, decoded)
+	if !changed {
+		return link, false
+	}
+	if decoded != p { // the link was percent-encoded
+		newPath = (&url.URL{Path: newPath}).EscapedPath()
+	}
+	return newPath + suffix, true
+}
+
+var (
+	// [text](target "title"), ![alt](<target>), and [id]: target
+	markdownLink = regexp.MustCompile(`\]\(\s*(?:<([^>\n]*)>|([^)\s]+))|(?m)^ {0,3}\[[^\]\n]+\]:\s*(?:<([^>\n]*)>|(\S+))`)
+
+	// href="target", src='target'
+	htmlLink = regexp.MustCompile(`(?i)\b(?:href|src)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+)
+
+func fixLinks(re *regexp.Regexp, dir, text string) (string, int) {
+	n := 0
+	text = replaceGroup(re, text, func(link string) string {
+		newLink, changed := rewriteLink(dir, link)
+		if changed {
+			n++
+		}
+		return newLink
+	})
+	return text, n
+}
+
+// markdown documents: relative link and image targets
+func fixMarkdown(dir, text string) (string, int) {
+	return fixLinks(markdownLink, dir, text)
+}
+
+// html documents: relative href and src attributes
+func fixHTML(dir, text string) (string, int) {
+	return fixLinks(htmlLink, dir, text)
+}
+
+// rewrite a file:// URL
+func rewriteFileURL(dir, s string) (string, bool) {
+	u, err := url.Parse(s)
+	if err != nil || !strings.EqualFold(u.Scheme, "file") {
+		return s, false
+	}
+	newPath, changed := rewriteRef(dir, u.Path)
+	if !changed {
+		return s, false
+	}
+	if i := strings.Index(s, u.Path); i >= 0 && !strings.Contains(s, "%") {
+		// keep an unescaped URL unescaped
+		return s[:i] + newPath + s[i+len(u.Path):], true
+	}
+	u.Path, u.RawPath = newPath, ""
+	return u.String(), true
+}
+
+// keys of a desktop entry holding paths, URLs or command lines
+var desktopKey = regexp.MustCompile(`^(\s*(Exec|TryExec|Path|Icon|URL)(?:\[[^\]]*\])?\s*=\s*)(.*)$`)
+
+// split a desktop entry Exec value into arguments, keeping quotes
+var desktopArg = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|\S+`)
+
+// Linux desktop entries: Exec, TryExec, Path, Icon and URL keys
+func fixDesktop(dir, text string) (string, int) {
+	n := 0
+	text = mapLines(text, func(line string) string {
+		m := desktopKey.FindStringSubmatch(line)
+		if m == nil {
+			return line
+		}
+		value, changed := m[3], false
+		switch m[2] {
+		case "URL":
+			value, changed = rewriteFileURL(dir, value)
+		case "Exec":
+			value = desktopArg.ReplaceAllStringFunc(value, func(arg string) string {
+				if strings.HasPrefix(arg, "%") { // field codes
+					return arg
+				}
+				quoted := strings.HasPrefix(arg, `"`)
+				ref := strings.Trim(arg, `"`)
+				newRef, c := rewriteRef(dir, ref)
+				if !c {
+					return arg
+				}
+				changed = true
+				if quoted {
+					return `"` + newRef + `"`
+				}
+				return newRef
+			})
+		default:
+			value, changed = rewriteRef(dir, value)
+		}
+		if !changed {
+			return line
+		}
+		n++
+		return m[1] + value
+	})
+	return text, n
+}
+
+var weblocURL = regexp.MustCompile(`<string>\s*(file://[^<]*?)\s*</string>`)
+
+// macOS .webloc files (XML property lists): file:// URLs
+func fixWebloc(dir, text string) (string, int) {
+	n := 0
+	text = replaceGroup(weblocURL, text, func(s string) string {
+		newURL, changed := rewriteFileURL(dir, s)
+		if changed {
+			n++
+		}
+		return newURL
+	})
+	return text, n
+}
+
+// attributes and elements of XMP sidecars naming the main file
+var xmpRef = regexp.MustCompile(`\b(?:xmpMM:DerivedFrom|crs:RawFileName)\s*=\s*(?:"([^"]*)"|'([^']*)')|<(?:xmpMM:DerivedFrom|crs:RawFileName)>([^<]*)</`)
+
+// XMP sidecars: references to the main file, as written by darktable and Adobe Camera Raw
+func fixXMP(dir, text string) (string, int) {
+	n := 0
+	text = replaceGroup(xmpRef, text, func(ref string) string {
+		newRef, changed := rewriteRef(dir, ref)
+		if changed {
+			n++
+		}
+		return newRef
+	})
+	return text, n
+}
+
+// update references in processed files of the given types
+func fixRefs(types map[string]bool) (err error) {
+	if len(types) == 0 || len(renamed) == 0 {
+		return
+	}
+	for _, name := range visited {
+		t := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+		if !types[t] {
+			continue
+		}
+		var data []byte
+		data, err = readFile(name)
+		if err != nil {
+			return
+		}
+		var abs string
+		abs, err = filepath.Abs(name)
+		if err != nil {
+			return
+		}
+		text, n := refFixers[t](filepath.Dir(abs), string(data))
+		if n == 0 {
+			continue
+		}
+		if !quiet {
+			fmt.Fprintf(stdout, "%s\n  (%d reference(s) updated)\n", name, n)
+		}
+		if dryrun {
+			continue
+		}
+		var fInfo os.FileInfo
+		fInfo, err = fsys.Stat(name)
+		if err != nil {
+			return
+		}
+		err = writeFile(name, []byte(text), fInfo.Mode().Perm())
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// update targets of processed symbolic links that point to renamed files
+func fixLinkTargets() (err error) {
+	if len(renamed) == 0 {
+		return
+	}
+	for _, name := range symlinks {
+		var target, abs string
+		target, err = fsys.Readlink(name)
+		if err != nil {
+			return
+		}
+		abs, err = filepath.Abs(name)
+		if err != nil {
+			return
+		}
+		newTarget, changed := rewriteRef(filepath.Dir(abs), target)
+		if !changed {
+			continue
+		}
+		if !quiet {
+			fmt.Fprintf(stdout, "%s\n  (link target updated to %s)\n", name, newTarget)
+		}
+		if dryrun {
+			continue
+		}
+		// replace the link atomically
+		tmp := name + ".nufn-tmp"
+		err = fsys.Symlink(newTarget, tmp)
+		if err != nil {
+			return
+		}
+		err = fsys.Rename(tmp, name)
+		if err != nil {
+			fsys.Remove(tmp)
+			return
+		}
+	}
+	return
+}
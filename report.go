@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// version of the JSON report format; increased on incompatible changes
+const reportVersion = 1
+
+// a JSON report of a run
+type report struct {
+	Version  int           `json:"version"`
+	Form     string        `json:"form"`
+	DryRun   bool          `json:"dryrun"`
+	Time     time.Time     `json:"time"`
+	Base     string        `json:"base,omitempty"` // directory of relative paths, with -relative-to
+	Entries  []reportEntry `json:"entries"`
+	Problems []problem     `json:"problems,omitempty"` // files not renamed
+	Pruned   []reportEntry `json:"pruned,omitempty"`   // duplicates removed, and the files kept
+}
+
+// a renamed file; paths are absolute
+type reportEntry struct {
+	Old     string   `json:"old"`
+	New     string   `json:"new"`
+	Form    string   `json:"form,omitempty"`    // set if not the form of the run
+	ID      string   `json:"id,omitempty"`      // device and inode, or volume and file index on Windows
+	Explain []string `json:"explain,omitempty"` // how the name changed, with -explain
+}
+
+var reportEntries []reportEntry
+
+// add a renamed file to the report
+func addReport(oldName, newName string, form norm.Form, id string, explanation []string) {
+	if e, ok := newReportEntry(oldName, newName, form); ok {
+		e.ID = id
+		e.Explain = explanation
+		reportEntries = append(reportEntries, e)
+	}
+}
+
+func newReportEntry(oldName, newName string, form norm.Form) (e reportEntry, ok bool) {
+	o, e1 := reportPath(oldName)
+	n, e2 := reportPath(newName)
+	if e1 != nil || e2 != nil {
+		return
+	}
+	e = reportEntry{Old: o, New: n}
+	if f := formString(form); f != runFormName() {
+		e.Form = f
+	}
+	return e, true
+}
+
+func writeReport(filename string) (err error) {
+	return saveReport(filename, report{DryRun: dryrun, Entries: reportEntries, Problems: problems, Pruned: pruned})
+}
+
+// write a report of this run
+func saveReport(filename string, r report) (err error) {
+	r.Version = reportVersion
+	r.Form = runFormName()
+	r.Time = time.Now()
+	r.Base = pathBase
+	if r.Entries == nil {
+		r.Entries = []reportEntry{}
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return
+	}
+	return os.WriteFile(filename, append(data, '\n'), 0644)
+}
+
+func readReport(filename string) (r *report, err error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return
+	}
+	r = new(report)
+	err = json.Unmarshal(data, r)
+	if err != nil {
+		return nil, err
+	}
+	if r.Version > reportVersion {
+		return nil, fmt.Errorf("%s: report version %d is newer than supported version %d", filename, r.Version, reportVersion)
+	}
+	return
+}
+
+// print the files needing renaming that are new or resolved since a previous report
+func printReportDiff(prev *report) {
+	current := make(map[string]bool)
+	for _, e := range reportEntries {
+		current[e.Old] = true
+	}
+	previous := make(map[string]bool)
+	for _, e := range prev.Entries {
+		previous[e.Old] = true
+	}
+
+	added, resolved := 0, 0
+	for _, e := range reportEntries {
+		if !previous[e.Old] {
+			fmt.Fprintf(stdout, "+ %s\n", e.Old)
+			added++
+		}
+	}
+	for _, e := range prev.Entries {
+		if !current[e.Old] {
+			fmt.Fprintf(stdout, "- %s\n", e.Old)
+			resolved++
+		}
+	}
+	fmt.Fprintf(stdout, "%d new, %d resolved, %d unchanged since %s\n",
+		added, resolved, len(reportEntries)-added, prev.Time.Format("2006-01-02 15:04:05"))
+}
+
+// JSON Schema of the report
+const reportSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$id": "https://github.com/mixcode/normalize-unicode-filename/report.schema.json",
+  "title": "normalize-unicode-filename report",
+  "type": "object",
+  "required": ["version", "form", "dryrun", "time", "entries"],
+  "properties": {
+    "version": {"const": 1},
+    "form": {"type": "string", "enum": ["NFC", "NFD", "NFKC", "NFKD", "AUTO", "CSV"]},
+    "dryrun": {"type": "boolean"},
+    "time": {"type": "string", "format": "date-time"},
+    "base": {"type": "string", "description": "absolute directory the paths are relative to, if written with -relative-to"},
+    "entries": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["old", "new"],
+        "properties": {
+          "old": {"type": "string", "description": "path before renaming; absolute, or relative to base"},
+          "new": {"type": "string", "description": "path after renaming; absolute, or relative to base"},
+          "form": {"type": "string", "enum": ["NFC", "NFD", "NFKC", "NFKD"], "description": "normalization form of the entry, if not the form of the run"},
+          "id": {"type": "string", "description": "stable file identifier: 'device:inode' in decimal, or 'volume:index' in hex on Windows"},
+          "explain": {"type": "array", "items": {"type": "string"}, "description": "how the name changed, with -explain"}
+        }
+      }
+    },
+    "pruned": {
+      "type": "array",
+      "description": "duplicates removed with -prune-identical",
+      "items": {
+        "type": "object",
+        "required": ["old", "new"],
+        "properties": {
+          "old": {"type": "string", "description": "path of the removed file"},
+          "new": {"type": "string", "description": "path of the identical file kept, with the normalized name"}
+        }
+      }
+    },
+    "problems": {
+      "type": "array",
+      "description": "files not renamed because of collisions or errors",
+      "items": {
+        "type": "object",
+        "required": ["path", "new", "reason"],
+        "properties": {
+          "path": {"type": "string", "description": "path of the file; absolute, or relative to base"},
+          "new": {"type": "string", "description": "the name it would be renamed to"},
+          "reason": {"type": "string"}
+        }
+      }
+    }
+  }
+}
+`
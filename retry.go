@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// the files a previous run could not rename and still exist, from the problems in its report
+func failedFiles(filename string) (names []string, err error) {
+	r, err := readReport(filename)
+	if err != nil {
+		return
+	}
+	for _, p := range r.Problems {
+		name := resolveReportPath(r, p.Path)
+		if _, e := fsys.Lstat(name); os.IsNotExist(e) {
+			warn(name, "no longer exists; skipped")
+			continue
+		}
+		names = append(names, name)
+	}
+	return
+}
+
+// true if a file is one of the roots, or would be reached from one of them
+func inRoots(name string, roots []string) bool {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return false
+	}
+	for _, r := range roots {
+		if a, err := filepath.Abs(r); err == nil {
+			if samePath(abs, a) || (recurse && isInside(abs, a)) || (children && samePath(filepath.Dir(abs), a)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// if the previous run writing the same report could not rename some files in the roots of this run,
+// offer to retry only those instead of processing everything again
+func offerRetry(filename string, roots []*root) (names []string) {
+	if dryrun || assumeYes {
+		return nil
+	}
+	if fInfo, err := os.Stdin.Stat(); err != nil || fInfo.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	var rootNames []string
+	for _, r := range roots {
+		l, err := expandArgs(r.patterns)
+		if err != nil {
+			return nil
+		}
+		rootNames = append(rootNames, l...)
+	}
+	failed, err := failedFiles(filename)
+	if err != nil {
+		return nil
+	}
+	var l []string
+	for _, name := range failed {
+		if inRoots(name, rootNames) {
+			l = append(l, name)
+		}
+	}
+	if len(l) == 0 {
+		return nil
+	}
+	fmt.Fprintf(stdout, "The previous run recorded in %s could not rename %d file(s).\n", filename, len(l))
+	fmt.Fprintf(stdout, "Retry only those? [y/N] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return l
+	}
+	return nil
+}
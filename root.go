@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// files to process with a normalization form
+type root struct {
+	patterns []string
+	names    []string // expanded from patterns, or given as they are
+	form     norm.Form
+	auto     bool // form chosen per file system
+}
+
+// -root values: a path with an optional ':FORM' suffix
+type rootFlag []string
+
+func (r *rootFlag) String() string {
+	return strings.Join(*r, ", ")
+}
+
+func (r *rootFlag) Set(s string) error {
+	*r = append(*r, s)
+	return nil
+}
+
+// resolve a normalization form name, alias or 'auto'
+func resolveForm(name string) (form norm.Form, auto bool, err error) {
+	if strings.EqualFold(strings.TrimSpace(name), "auto") {
+		return defaultForm(), true, nil
+	}
+	form, err = parseForm(name)
+	return
+}
+
+// split a root into its path and form; the form suffix is optional, so that
+// paths containing ':' (e.g. C:\data on Windows) are kept whole. A suffix that is
+// neither a form nor part of an existing path is taken as a mistyped form.
+func parseRoot(s string) (path, form string, err error) {
+	i := strings.LastIndex(s, ":")
+	if i <= 0 {
+		return s, "", nil
+	}
+	if _, _, e := resolveForm(s[i+1:]); e == nil {
+		return s[:i], s[i+1:], nil
+	}
+	if l, e := fsys.Glob(s); e == nil && len(l) > 0 {
+		return s, "", nil
+	}
+	return "", "", fmt.Errorf("root '%s': '%s' is neither a normalization form nor part of an existing path", s, s[i+1:])
+}
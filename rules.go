@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// settings for files matching patterns, from a '[rule PATTERN...]' section of the configuration.
+// Unset settings are left as given on the command line.
+type rule struct {
+	patterns  []string
+	skip      bool       // neither renamed nor searched
+	form      *norm.Form // normalization form
+	canonical *bool      // only canonically equivalent changes, like -max-visual-change
+	control   *string    // replacement of control characters, like -replace-control
+	invalid   *string    // replacement of invalid characters, like -replace-invalid
+}
+
+// parse the patterns of a rule section: base names like '*.url', or 'dir/**' for a directory
+// and everything in it
+func parseRulePatterns(s string) (l []string, err error) {
+	l = strings.Fields(s)
+	if len(l) == 0 {
+		return nil, fmt.Errorf("a rule needs a pattern")
+	}
+	for _, p := range l {
+		base := strings.TrimSuffix(p, "/**")
+		if strings.ContainsAny(base, `/\`) {
+			return nil, fmt.Errorf("pattern '%s': only names and 'dir/**' are supported", p)
+		}
+		if _, err = filepath.Match(base, ""); err != nil {
+			return nil, fmt.Errorf("pattern '%s': %w", p, err)
+		}
+	}
+	return
+}
+
+// set a key of a rule
+func (r *rule) set(key, value string) (err error) {
+	switch key {
+	case "skip":
+		r.skip, err = parseBool(value)
+	case "form":
+		var f norm.Form
+		f, err = parseForm(value)
+		r.form = &f
+	case "max-visual-change":
+		var b bool
+		b, err = parseBool(value)
+		r.canonical = &b
+	case "replace-control":
+		err = checkReplacement(value)
+		r.control = &value
+	case "replace-invalid":
+		err = checkInvalidReplacement(value)
+		r.invalid = &value
+	default:
+		err = fmt.Errorf("unknown rule setting '%s'", key)
+	}
+	return
+}
+
+// true if a rule applies to a file
+func (r *rule) match(name string, isDir bool) bool {
+	base := filepath.Base(name)
+	for _, p := range r.patterns {
+		if dirPattern := strings.TrimSuffix(p, "/**"); dirPattern != p {
+			// any directory on the path
+			dirs := strings.Split(filepath.ToSlash(filepath.Dir(name)), "/")
+			if isDir {
+				dirs = append(dirs, base)
+			}
+			for _, d := range dirs {
+				if ok, _ := filepath.Match(dirPattern, d); ok {
+					return true
+				}
+			}
+		} else if ok, _ := filepath.Match(p, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// the first rule applying to a file, or nil
+func matchRule(name string, isDir bool) *rule {
+	for _, r := range conf.rules {
+		if r.match(name, isDir) {
+			return r
+		}
+	}
+	return nil
+}
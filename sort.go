@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// a renamed file printed with -sort, kept until the end of the run
+type result struct {
+	key  string
+	text bytes.Buffer
+}
+
+var (
+	sortMode = ""      // "", "bytewise" or "normalized"
+	results  []*result // printed results, with -sort
+)
+
+func parseSort(s string) (err error) {
+	switch strings.ToLower(s) {
+	case "none", "":
+		sortMode = ""
+	case "bytewise", "normalized":
+		sortMode = strings.ToLower(s)
+	default:
+		err = fmt.Errorf("invalid sort order '%s'; one of normalized, bytewise, none", s)
+	}
+	return
+}
+
+// the writer to print a renamed file to; buffered with -sort
+func resultWriter(key string) io.Writer {
+	if sortMode == "" {
+		return stdout
+	}
+	r := &result{key: key}
+	results = append(results, r)
+	return &r.text
+}
+
+// the language of the locale, for collation
+func collateLanguage() language.Tag {
+	for _, v := range []string{"LC_ALL", "LC_COLLATE", "LANG"} {
+		s := os.Getenv(v)
+		if s == "" {
+			continue
+		}
+		s, _, _ = strings.Cut(s, ".") // ko_KR.UTF-8
+		if t, err := language.Parse(strings.ReplaceAll(s, "_", "-")); err == nil {
+			return t
+		}
+	}
+	return language.Und
+}
+
+// print the results in order
+func printResults() {
+	if sortMode == "" {
+		return
+	}
+	less := func(i, j int) bool { return results[i].key < results[j].key }
+	if sortMode == "normalized" {
+		c := collate.New(collateLanguage())
+		less = func(i, j int) bool {
+			if d := c.CompareString(results[i].key, results[j].key); d != 0 {
+				return d < 0
+			}
+			return results[i].key < results[j].key
+		}
+	}
+	sort.SliceStable(results, less)
+	for _, r := range results {
+		stdout.Write(r.text.Bytes())
+	}
+}
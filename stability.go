@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/unicode/rangetable"
+	"golang.org/x/text/unicode/runenames"
+)
+
+// Unicode versions with tables of assigned characters, oldest first
+var unicodeVersions = []string{
+	"4.1.0", "5.0.0", "5.1.0", "5.2.0", "6.0.0", "6.1.0", "6.2.0", "6.3.0",
+	"7.0.0", "8.0.0", "9.0.0", "10.0.0", "11.0.0", "12.0.0", "13.0.0", "15.0.0",
+}
+
+// check a Unicode version like 6.2 or 6.2.0
+func parseUnicodeVersion(s string) (v string, err error) {
+	v = s
+	for strings.Count(v, ".") < 2 {
+		v += ".0"
+	}
+	if rangetable.Assigned(v) == nil {
+		return "", fmt.Errorf("unknown Unicode version '%s'; one of %s", s, strings.Join(unicodeVersions, ", "))
+	}
+	return
+}
+
+// the first Unicode version a character is assigned in, or "" if unassigned
+func unicodeAge(r rune) string {
+	for _, v := range unicodeVersions {
+		if t := rangetable.Assigned(v); t != nil && unicode.Is(t, r) {
+			return v
+		}
+	}
+	return ""
+}
+
+// true if a character takes part in normalization, by decomposing, reordering or composing
+func normalizes(r rune) bool {
+	s := string(r)
+	return len(norm.NFKD.PropertiesString(s).Decomposition()) > 0 ||
+		norm.NFD.PropertiesString(s).CCC() != 0 ||
+		!norm.NFC.PropertiesString(s).BoundaryBefore()
+}
+
+// a description of the first character of a name that runtimes of an older Unicode version,
+// which treat it as unassigned, would normalize differently; or "" if there is none
+func unstableChar(name, version string) string {
+	old := rangetable.Assigned(version)
+	for _, r := range name {
+		if unicode.Is(old, r) {
+			continue
+		}
+		age := unicodeAge(r)
+		if age == "" {
+			return fmt.Sprintf("U+%04X is unassigned in Unicode %s; a later version may normalize it", r, norm.Version)
+		}
+		if normalizes(r) {
+			return fmt.Sprintf("U+%04X %s is new in Unicode %s and not normalized by Unicode %s runtimes", r, runenames.Name(r), age, version)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// the state of a fully processed directory
+type dirState struct {
+	ModTime int64    `json:"mtime"`   // modification time in nanoseconds
+	Form    string   `json:"form"`    // the form of its files, after .nufnrc files
+	Subdirs []string `json:"subdirs"` // names of subdirectories
+}
+
+// state kept between runs to skip unchanged directories
+type stateFile struct {
+	Form     string               `json:"form"`
+	Settings string               `json:"settings"` // other settings deciding new names
+	Dirs     map[string]*dirState `json:"dirs"`     // by absolute path
+}
+
+// the settings besides the form that decide new names: replacements, -max-visual-change,
+// aliases and rules. A state kept with other settings is not used.
+func stateSettings() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "control=%q invalid=%q canonical=%v aliases=%q", controlReplacement, invalidReplacement, canonicalOnly, aliasList())
+	for _, r := range conf.rules {
+		fmt.Fprintf(&b, "; rule %q skip=%v", r.patterns, r.skip)
+		if r.form != nil {
+			fmt.Fprintf(&b, " form=%s", formString(*r.form))
+		}
+		if r.canonical != nil {
+			fmt.Fprintf(&b, " canonical=%v", *r.canonical)
+		}
+		if r.control != nil {
+			fmt.Fprintf(&b, " control=%q", *r.control)
+		}
+		if r.invalid != nil {
+			fmt.Fprintf(&b, " invalid=%q", *r.invalid)
+		}
+	}
+	return b.String()
+}
+
+var (
+	state      *stateFile
+	stateStart = time.Now()
+)
+
+// load the state file; a missing file or a state for another form starts afresh
+func loadState(filename string) (err error) {
+	state = &stateFile{Form: runFormName(), Settings: stateSettings(), Dirs: make(map[string]*dirState)}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+	var s stateFile
+	err = json.Unmarshal(data, &s)
+	if err != nil {
+		return
+	}
+	if s.Form == state.Form && s.Settings == state.Settings && s.Dirs != nil {
+		state = &s
+	}
+	return
+}
+
+func saveState(filename string) (err error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return
+	}
+	// write to a temporary file first not to lose the state on failure
+	tmp := filename + ".tmp"
+	err = os.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return
+	}
+	return os.Rename(tmp, filename)
+}
+
+// the recorded subdirectories of a directory, if it is unchanged since the last run
+// and its files are in the same form
+func unchangedDir(name string, fInfo os.FileInfo, form norm.Form) (subdirs []string, ok bool) {
+	if state == nil {
+		return
+	}
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return
+	}
+	s := state.Dirs[abs]
+	if s == nil || s.ModTime != fInfo.ModTime().UnixNano() || s.Form != formString(form) {
+		return
+	}
+	return s.Subdirs, true
+}
+
+// record a processed directory; a directory with files left unexamined
+// or needing a change (complete is false) is processed again in the next run
+func recordDirState(name string, form norm.Form, complete bool) {
+	if state == nil || dryrun {
+		return
+	}
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return
+	}
+	if !complete || len(pending) > 0 {
+		delete(state.Dirs, abs)
+		return
+	}
+	fInfo, err := fsys.Stat(abs)
+	if err != nil {
+		return
+	}
+	// a directory modified during the run may change again within the timestamp resolution
+	if !fInfo.ModTime().Before(stateStart.Add(-2 * time.Second)) {
+		delete(state.Dirs, abs)
+		return
+	}
+	d, err := fsys.ReadDir(abs)
+	if err != nil {
+		return
+	}
+	s := &dirState{ModTime: fInfo.ModTime().UnixNano(), Form: formString(form), Subdirs: []string{}}
+	for _, f := range d {
+		if f.IsDir() {
+			s.Subdirs = append(s.Subdirs, f.Name())
+		}
+	}
+	state.Dirs[abs] = s
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// checks of a name against the file naming constraints of an OS
+type targetOS struct {
+	name string
+
+	// problems of a file with the base name and the full path
+	check func(base, path string) []string
+
+	// replace characters of a base name not allowed on the OS
+	sanitize func(base string) string
+}
+
+var targets = []targetOS{
+	{"windows", checkWindows, sanitizeWindows},
+	{"linux", checkLinux, sanitizeLinux},
+	{"macos", checkMacOS, sanitizeMacOS},
+}
+
+func findTarget(name string) (*targetOS, error) {
+	for i := range targets {
+		if strings.EqualFold(targets[i].name, name) {
+			return &targets[i], nil
+		}
+	}
+	return nil, fmt.Errorf("invalid target OS '%s'; one of windows, linux, macos", name)
+}
+
+// length of a string in UTF-16 code units
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+var windowsReserved = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+func checkWindows(base, path string) (l []string) {
+	if strings.ContainsAny(base, `<>:"/\|?*`) {
+		l = append(l, "invalid character")
+	}
+	if hasControlChars(base) {
+		l = append(l, "control character")
+	}
+	stem, _, _ := strings.Cut(base, ".")
+	if windowsReserved[strings.ToUpper(strings.TrimRight(stem, " "))] {
+		l = append(l, "reserved name")
+	}
+	if strings.HasSuffix(base, ".") || strings.HasSuffix(base, " ") {
+		l = append(l, "trailing dot or space")
+	}
+	if utf16Len(base) > 255 {
+		l = append(l, "name longer than 255 characters")
+	}
+	if utf16Len(path) > 259 {
+		l = append(l, "path longer than 260 characters (MAX_PATH)")
+	}
+	return
+}
+
+func sanitizeWindows(base string) string {
+	base = strings.Map(func(r rune) rune {
+		if isControl(r) || strings.ContainsRune(`<>:"/\|?*`, r) {
+			return '_'
+		}
+		return r
+	}, base)
+	base = strings.TrimRight(base, ". ")
+	stem, ext, _ := strings.Cut(base, ".")
+	if windowsReserved[strings.ToUpper(strings.TrimRight(stem, " "))] {
+		base = stem + "_"
+		if ext != "" {
+			base += "." + ext
+		}
+	}
+	if base == "" {
+		base = "_"
+	}
+	return base
+}
+
+func checkLinux(base, path string) (l []string) {
+	if len(base) > 255 {
+		l = append(l, "name longer than 255 bytes")
+	}
+	if len(path) > 4095 {
+		l = append(l, "path longer than 4096 bytes")
+	}
+	return
+}
+
+func sanitizeLinux(base string) string {
+	return base
+}
+
+func checkMacOS(base, path string) (l []string) {
+	if strings.Contains(base, ":") {
+		l = append(l, "invalid character")
+	}
+	if utf16Len(base) > 255 {
+		l = append(l, "name longer than 255 characters")
+	}
+	if len(path) > 1023 {
+		l = append(l, "path longer than 1024 bytes")
+	}
+	return
+}
+
+func sanitizeMacOS(base string) string {
+	return strings.ReplaceAll(base, ":", "_")
+}
+
+var (
+	target  *targetOS   // with -target
+	checked []*targetOS // targets names are checked against, with -target and -compat
+	stats   = make(map[string]*targetStat)
+)
+
+// results of checks against a target
+type targetStat struct {
+	total  int            // number of files checked
+	failed int            // number of files with problems
+	counts map[string]int // number of files by problem
+}
+
+// check a normalized file name against the target OS and legacy targets, and warn on problems
+func checkTarget(newName, base string) {
+	path := newName // path limits apply to the full path
+	if abs, err := filepath.Abs(newName); err == nil {
+		path = abs
+	}
+	for _, t := range checked {
+		st := stats[t.name]
+		if st == nil {
+			st = &targetStat{counts: make(map[string]int)}
+			stats[t.name] = st
+		}
+		st.total++
+		l := t.check(base, path)
+		if len(l) == 0 {
+			continue
+		}
+		st.failed++
+		for _, p := range l {
+			st.counts[p]++
+		}
+		warn(newName, "%s: %s", t.name, strings.Join(l, ", "))
+	}
+}
+
+// print the summary of target checks
+func printTargetSummary() {
+	for _, t := range checked {
+		st := stats[t.name]
+		if st == nil {
+			st = &targetStat{}
+		}
+		fmt.Fprintf(stdout, "target %s: %d of %d file(s) have problems\n", t.name, st.failed, st.total)
+		kinds := make([]string, 0, len(st.counts))
+		for p := range st.counts {
+			kinds = append(kinds, p)
+		}
+		sort.Strings(kinds)
+		for _, p := range kinds {
+			fmt.Fprintf(stdout, "  %s: %d\n", p, st.counts[p])
+		}
+	}
+}
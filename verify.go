@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+func init() {
+	commands = append(commands, &command{
+		name:     "verify",
+		synopsis: "report.json",
+		brief:    "check that files renamed in a previous run still have their normalized names",
+		run:      runVerify,
+	})
+}
+
+func runVerify(fs *flag.FlagSet, args []string) (err error) {
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	r, err := readReport(fs.Arg(0))
+	if err != nil {
+		return
+	}
+	if r.DryRun {
+		return fmt.Errorf("%s: the report is from a dry run", fs.Arg(0))
+	}
+
+	// exact names in directories
+	listings := make(map[string][]string)
+	list := func(dir string) []string {
+		l, ok := listings[dir]
+		if !ok {
+			d, _ := os.ReadDir(dir)
+			for _, f := range d {
+				l = append(l, f.Name())
+			}
+			listings[dir] = l
+		}
+		return l
+	}
+
+	drift := 0
+	for _, e := range r.Entries {
+		dir, base := filepath.Split(resolveReportPath(r, e.New))
+		found, equivalent := false, ""
+		for _, n := range list(dir) {
+			if n == base {
+				found = true
+				break
+			}
+			if norm.NFD.String(n) == norm.NFD.String(base) {
+				equivalent = n
+			}
+		}
+		switch {
+		case found:
+			continue
+		case equivalent != "":
+			fmt.Printf("%s\n  (no longer %s: renamed to %q)\n", e.New, r.Form, equivalent)
+		default:
+			fmt.Printf("%s\n  (missing)\n", e.New)
+		}
+		drift++
+	}
+
+	if drift > 0 {
+		return fmt.Errorf("%d of %d file(s) drifted", drift, len(r.Entries))
+	}
+	return
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// build information; may be set with -ldflags "-X main.version=v1.2.3 ..."
+var (
+	version   = ""
+	commit    = ""
+	buildDate = ""
+)
+
+// the module providing the Unicode normalization tables
+const textModule = "golang.org/x/text"
+
+func printVersion(o io.Writer, execName string) {
+	ver, rev, date, textVer := version, commit, buildDate, ""
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		dirty := false
+		if ver == "" {
+			ver = info.Main.Version
+		}
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if rev == "" {
+					rev = s.Value
+				}
+			case "vcs.time":
+				if date == "" {
+					date = s.Value
+				}
+			case "vcs.modified":
+				dirty = s.Value == "true"
+			}
+		}
+		if dirty && commit == "" && rev != "" {
+			rev += "-dirty"
+		}
+		for _, dep := range info.Deps {
+			if dep.Path != textModule {
+				continue
+			}
+			textVer = dep.Version
+			if dep.Replace != nil {
+				textVer = dep.Replace.Path + " " + dep.Replace.Version
+			}
+		}
+	}
+
+	unknown := func(s string) string {
+		if s == "" {
+			return "unknown"
+		}
+		return s
+	}
+
+	fmt.Fprintf(o, "%s %s\n", execName, unknown(ver))
+	fmt.Fprintf(o, "  commit:  %s\n", unknown(rev))
+	fmt.Fprintf(o, "  built:   %s\n", unknown(date))
+	fmt.Fprintf(o, "  go:      %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(o, "  %s: %s (Unicode %s)\n", textModule, unknown(textVer), norm.Version)
+}
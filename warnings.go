@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// how diagnostics are written to stderr: "text", "json" or "off"
+var warningsMode = "text"
+
+func parseWarnings(s string) (err error) {
+	switch strings.ToLower(s) {
+	case "text", "json", "off":
+		warningsMode = strings.ToLower(s)
+	default:
+		err = fmt.Errorf("invalid warnings output '%s'; one of text, json, off", s)
+	}
+	return
+}
+
+// a diagnostic message about a file, or about the run if name is empty
+type diagnostic struct {
+	Level   string `json:"level"` // "error", "warning" or "notice"
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+// write a diagnostic to stderr, apart from the results on stdout
+func diag(level, name, msg string) {
+	switch warningsMode {
+	case "off":
+	case "json":
+		if !utf8.ValidString(name) { // JSON strings cannot hold invalid bytes
+			name = fmt.Sprintf("%q", name)
+		}
+		data, _ := json.Marshal(diagnostic{Level: level, Path: name, Message: msg})
+		fmt.Fprintf(stderr, "%s\n", data)
+	default:
+		if name == "" {
+			fmt.Fprintln(stderr, msg)
+			return
+		}
+		// control characters and invalid bytes would garble the terminal
+		if invalidName(name) != "" || hasControlChars(name) {
+			name = fmt.Sprintf("%q", name)
+		}
+		fmt.Fprintf(stderr, "%s: %s\n", name, msg)
+	}
+}
+
+// a message about the run as a whole
+func notice(format string, a ...interface{}) {
+	diag("notice", "", fmt.Sprintf(format, a...))
+}